@@ -21,6 +21,7 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,7 +31,15 @@ import (
 	"github.com/protogalaxy/service-tictactoe-game/tictactoe"
 )
 
-var port = flag.Int("port", 9090, "port to listen on")
+var (
+	port       = flag.Int("port", 9090, "port to listen on")
+	publishers = flag.String("publisher", "kafka", "comma separated list of event sinks (kafka, memory)")
+	storeDir   = flag.String("store", "", "directory to persist games in, games are kept in memory if empty")
+	replayFrom = flag.String("replay-from", "", "rebuild games from the kafka event stream starting at this offset (oldest or a number)")
+
+	keepFinished = flag.Duration("keep-finished", 10*time.Minute, "how long finished games are kept, 0 keeps them forever")
+	keepIdle     = flag.Duration("keep-idle", 24*time.Hour, "how long unfinished games without moves are kept, 0 keeps them forever")
+)
 
 func ParseLinkEnv(name string) string {
 	v := os.Getenv(name + "_PORT")
@@ -41,6 +50,33 @@ func ParseLinkEnv(name string) string {
 	return connStr
 }
 
+func replayEvents(manager *tictactoe.GameManager, from string) {
+	offset := sarama.OffsetOldest
+	if from != "oldest" {
+		var err error
+		offset, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			glog.Fatalf("Invalid replay offset: %s", from)
+		}
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.ClientID = "service-tictactoe-game"
+	client, err := sarama.NewClient([]string{ParseLinkEnv("KAFKA")}, cfg)
+	if err != nil {
+		glog.Fatalf("Unable to connect to kafka: %s", err)
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			glog.Errorf("Error closing client: %s", err)
+		}
+	}()
+
+	if err := manager.ReplayKafka(client, tictactoe.StreamTopic, offset); err != nil {
+		glog.Fatalf("Unable to replay game events: %s", err)
+	}
+}
+
 func main() {
 	flag.Parse()
 	rand.Seed(time.Now().UnixNano())
@@ -50,19 +86,58 @@ func main() {
 		glog.Fatalf("failed to listen: %v", err)
 	}
 
-	cfg := sarama.NewConfig()
-	cfg.ClientID = "service-tictactoe-game"
-	producer, err := sarama.NewSyncProducer([]string{ParseLinkEnv("KAFKA")}, cfg)
-	if err != nil {
-		glog.Fatalf("Unable to connect to kafka: %s", err)
+	var sinks []tictactoe.EventPublisher
+	for _, name := range strings.Split(*publishers, ",") {
+		switch name {
+		case "kafka":
+			cfg := sarama.NewConfig()
+			cfg.ClientID = "service-tictactoe-game"
+			producer, err := sarama.NewSyncProducer([]string{ParseLinkEnv("KAFKA")}, cfg)
+			if err != nil {
+				glog.Fatalf("Unable to connect to kafka: %s", err)
+			}
+			defer func() {
+				if err := producer.Close(); err != nil {
+					glog.Errorf("Error closing producer: %s", err)
+				}
+			}()
+			sinks = append(sinks, tictactoe.NewKafkaPublisher(producer, tictactoe.StreamTopic))
+		case "memory":
+			sinks = append(sinks, tictactoe.NewMemoryPublisher())
+		default:
+			glog.Fatalf("Unknown event publisher: %s", name)
+		}
 	}
-	defer func() {
-		if err := producer.Close(); err != nil {
-			glog.Errorf("Error closing producer: %s", err)
+	publisher := sinks[0]
+	if len(sinks) > 1 {
+		publisher = tictactoe.NewFanOutPublisher(sinks...)
+	}
+
+	var store tictactoe.GameStore = tictactoe.NewMemoryStore()
+	if *storeDir != "" {
+		store, err = tictactoe.NewFileStore(*storeDir)
+		if err != nil {
+			glog.Fatalf("Unable to open game store: %s", err)
 		}
-	}()
+	}
+
+	manager := tictactoe.NewGameManager(publisher, store)
+	defer manager.Close()
+	// Nothing may time out or move before the games are up to date.
+	manager.HoldTimeouts()
+	if err := manager.LoadGames(); err != nil {
+		glog.Fatalf("Unable to load games: %s", err)
+	}
+	if *replayFrom != "" {
+		replayEvents(manager, *replayFrom)
+	}
+	manager.ReleaseTimeouts()
+	manager.StartJanitor(tictactoe.RetentionPolicy{
+		Finished: *keepFinished,
+		Idle:     *keepIdle,
+	})
 
 	grpcServer := grpc.NewServer()
-	tictactoe.RegisterGameManagerServer(grpcServer, tictactoe.NewGameManager(producer))
+	tictactoe.RegisterGameManagerServer(grpcServer, manager)
 	grpcServer.Serve(socket)
 }
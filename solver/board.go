@@ -0,0 +1,120 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package solver solves m,n,k-games (tic-tac-toe on any board) by exhaustive
+// search. Positions are cached in a transposition table keyed by a Zobrist
+// hash that is the same for all 8 symmetries of a square board.
+package solver
+
+import "errors"
+
+// Player is the owner of a square.
+type Player uint8
+
+const (
+	Empty Player = iota
+	First
+	Second
+)
+
+// Opponent returns the other player.
+func (p Player) Opponent() Player {
+	return 3 - p
+}
+
+var ErrInvalidBoard = errors.New("invalid board")
+
+// Board is an m,n,k-game board: the first player to get WinLength squares
+// in a row, column or diagonal wins.
+type Board struct {
+	width, height, winLength int
+	squares                  []Player
+	free                     int
+}
+
+func NewBoard(width, height, winLength int) (*Board, error) {
+	if width < 1 || height < 1 || winLength < 1 || winLength > width && winLength > height {
+		return nil, ErrInvalidBoard
+	}
+	return &Board{
+		width:     width,
+		height:    height,
+		winLength: winLength,
+		squares:   make([]Player, width*height),
+		free:      width * height,
+	}, nil
+}
+
+func (b *Board) Width() int     { return b.width }
+func (b *Board) Height() int    { return b.height }
+func (b *Board) WinLength() int { return b.winLength }
+
+func (b *Board) Get(x, y int) Player {
+	return b.squares[y*b.width+x]
+}
+
+func (b *Board) Set(x, y int, p Player) {
+	b.set(y*b.width+x, p)
+}
+
+func (b *Board) set(i int, p Player) {
+	switch {
+	case b.squares[i] == Empty && p != Empty:
+		b.free -= 1
+	case b.squares[i] != Empty && p == Empty:
+		b.free += 1
+	}
+	b.squares[i] = p
+}
+
+func (b *Board) isFull() bool {
+	return b.free == 0
+}
+
+var directions = [4][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
+
+// wins reports whether the mark on square i completes a line.
+func (b *Board) wins(i int) bool {
+	p := b.squares[i]
+	x, y := i%b.width, i/b.width
+	for _, d := range directions {
+		n := 1 + b.run(x, y, d[0], d[1], p) + b.run(x, y, -d[0], -d[1], p)
+		if n >= b.winLength {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Board) run(x, y, dx, dy int, p Player) int {
+	n := 0
+	for {
+		x, y = x+dx, y+dy
+		if x < 0 || y < 0 || x >= b.width || y >= b.height || b.squares[y*b.width+x] != p {
+			return n
+		}
+		n += 1
+	}
+}
+
+// Winner returns the player with a line on the board or Empty.
+func (b *Board) Winner() Player {
+	for i, p := range b.squares {
+		if p != Empty && b.wins(i) {
+			return p
+		}
+	}
+	return Empty
+}
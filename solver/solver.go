@@ -0,0 +1,401 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package solver
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Score of a won position. Scores are relative to the position: a win in d
+// moves scores winScore-d.
+const winScore = 1 << 14
+
+const (
+	// DefaultTableSize is the number of positions cached by default.
+	DefaultTableSize = 1 << 20
+	// Seed of the Zobrist keys. Fixed so hashes are stable across runs.
+	zobristSeed = 0x7469637461
+)
+
+var ErrNodeLimit = errors.New("solver: node limit exceeded")
+
+// Outcome is the result of a position for the player to move.
+type Outcome int8
+
+const (
+	Loss Outcome = -1
+	Draw Outcome = 0
+	Win  Outcome = 1
+)
+
+// Result is the outcome of a position with best play by both players and
+// the number of moves until the game is decided. Draws have no distance.
+type Result struct {
+	Outcome  Outcome
+	Distance int
+}
+
+// Move is a move and the result it leads to for the player making it.
+type Move struct {
+	X, Y   int
+	Result Result
+}
+
+// Stats counts the work done by a solver.
+type Stats struct {
+	Nodes   uint64
+	Lookups uint64
+	Hits    uint64
+}
+
+// HitRate is the share of table lookups that found a position.
+func (s Stats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+type bound uint8
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+type entry struct {
+	score int16
+	bound bound
+}
+
+// Solver solves positions on boards of one size. Solved positions are
+// kept in the table for later calls. A solver must not be used by more
+// than one goroutine at a time.
+type Solver struct {
+	width, height, winLength int
+
+	// keys holds a Zobrist key per square and player, symmetries maps
+	// every square to its image under each symmetry of the board.
+	keys       [][2]uint64
+	sideKey    uint64
+	symmetries [][]int
+	order      []int
+
+	table     map[uint64]entry
+	tableSize int
+	nodeLimit uint64
+	stats     Stats
+
+	board  *Board
+	hashes []uint64
+}
+
+// New creates a solver for boards of the given size.
+func New(width, height, winLength int) (*Solver, error) {
+	b, err := NewBoard(width, height, winLength)
+	if err != nil {
+		return nil, err
+	}
+	s := &Solver{
+		width:     width,
+		height:    height,
+		winLength: winLength,
+		table:     make(map[uint64]entry),
+		tableSize: DefaultTableSize,
+		board:     b,
+	}
+	r := rand.New(rand.NewSource(zobristSeed))
+	s.keys = make([][2]uint64, width*height)
+	for i := range s.keys {
+		s.keys[i] = [2]uint64{uint64(r.Int63())<<1 ^ uint64(r.Int63()), uint64(r.Int63())<<1 ^ uint64(r.Int63())}
+	}
+	s.sideKey = uint64(r.Int63())<<1 ^ uint64(r.Int63())
+	s.symmetries = symmetries(width, height)
+	s.hashes = make([]uint64, len(s.symmetries))
+	s.order = centerOrder(width, height)
+	return s, nil
+}
+
+// SetTableSize limits the number of cached positions. The table is
+// cleared when it is full.
+func (s *Solver) SetTableSize(n int) {
+	s.tableSize = n
+}
+
+// SetNodeLimit makes Solve and Moves give up after searching n
+// positions. Zero means no limit.
+func (s *Solver) SetNodeLimit(n uint64) {
+	s.nodeLimit = n
+}
+
+// Stats returns the work done by the last call to Solve or Moves.
+func (s *Solver) Stats() Stats {
+	return s.stats
+}
+
+// symmetries returns the square permutations of the symmetries of the
+// board: all 8 for square boards, the 4 that keep the shape otherwise.
+func symmetries(width, height int) [][]int {
+	type transform func(x, y int) (int, int)
+	w, h := width-1, height-1
+	ts := []transform{
+		func(x, y int) (int, int) { return x, y },
+		func(x, y int) (int, int) { return w - x, y },
+		func(x, y int) (int, int) { return x, h - y },
+		func(x, y int) (int, int) { return w - x, h - y },
+	}
+	if width == height {
+		ts = append(ts,
+			func(x, y int) (int, int) { return y, x },
+			func(x, y int) (int, int) { return h - y, x },
+			func(x, y int) (int, int) { return y, w - x },
+			func(x, y int) (int, int) { return h - y, w - x },
+		)
+	}
+	perms := make([][]int, len(ts))
+	for i, t := range ts {
+		perms[i] = make([]int, width*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				tx, ty := t(x, y)
+				perms[i][y*width+x] = ty*width + tx
+			}
+		}
+	}
+	return perms
+}
+
+// centerOrder lists the squares from the center outwards. Central squares
+// are part of more lines, so trying them first prunes more.
+func centerOrder(width, height int) []int {
+	order := make([]int, 0, width*height)
+	for d := 0; len(order) < width*height; d++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if maxInt(abs(2*x-(width-1)), abs(2*y-(height-1)))/2 == d {
+					order = append(order, y*width+x)
+				}
+			}
+		}
+	}
+	return order
+}
+
+func (s *Solver) place(i int, p Player) {
+	for k, perm := range s.symmetries {
+		s.hashes[k] ^= s.keys[perm[i]][p-1]
+	}
+	s.board.set(i, p)
+}
+
+func (s *Solver) remove(i int, p Player) {
+	for k, perm := range s.symmetries {
+		s.hashes[k] ^= s.keys[perm[i]][p-1]
+	}
+	s.board.set(i, Empty)
+}
+
+// key is the smallest hash of the position under all symmetries.
+func (s *Solver) key(toMove Player) uint64 {
+	k := s.hashes[0]
+	for _, h := range s.hashes[1:] {
+		if h < k {
+			k = h
+		}
+	}
+	if toMove == Second {
+		k ^= s.sideKey
+	}
+	return k
+}
+
+func (s *Solver) load(b *Board) error {
+	if b.width != s.width || b.height != s.height || b.winLength != s.winLength {
+		return ErrInvalidBoard
+	}
+	s.stats = Stats{}
+	for k := range s.hashes {
+		s.hashes[k] = 0
+	}
+	for i := range s.board.squares {
+		s.board.set(i, Empty)
+	}
+	for i, p := range b.squares {
+		if p != Empty {
+			s.place(i, p)
+		}
+	}
+	return nil
+}
+
+// Solve returns the result of the position for the player to move. The
+// board must not be decided yet.
+func (s *Solver) Solve(b *Board, toMove Player) (Result, error) {
+	if err := s.load(b); err != nil {
+		return Result{}, err
+	}
+	if b.Winner() != Empty || b.isFull() {
+		return Result{}, ErrInvalidBoard
+	}
+	score, err := s.search(toMove, -winScore, winScore)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(score), nil
+}
+
+// Moves returns the result of every legal move of the player to move.
+func (s *Solver) Moves(b *Board, toMove Player) ([]Move, error) {
+	if err := s.load(b); err != nil {
+		return nil, err
+	}
+	if b.Winner() != Empty || b.isFull() {
+		return nil, ErrInvalidBoard
+	}
+	var moves []Move
+	for _, i := range s.order {
+		if s.board.squares[i] != Empty {
+			continue
+		}
+		score, err := s.tryMove(i, toMove, -winScore, winScore)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, Move{X: i % s.width, Y: i / s.width, Result: result(score)})
+	}
+	return moves, nil
+}
+
+func result(score int) Result {
+	switch {
+	case score > 0:
+		return Result{Outcome: Win, Distance: winScore - score}
+	case score < 0:
+		return Result{Outcome: Loss, Distance: winScore + score}
+	}
+	return Result{Outcome: Draw}
+}
+
+// parent converts the score of a position to the score of the move that
+// leads to it: a win for the opponent in d moves is a loss in d+1.
+func parent(score int) int {
+	switch {
+	case score > 0:
+		return -score + 1
+	case score < 0:
+		return -score - 1
+	}
+	return 0
+}
+
+// child is the inverse of parent. It converts a search window to the
+// window of the position after a move.
+func child(score int) int {
+	switch {
+	case score > 0:
+		return -score - 1
+	case score < 0:
+		return -score + 1
+	}
+	return 0
+}
+
+// tryMove returns the score of playing square i.
+func (s *Solver) tryMove(i int, p Player, alpha, beta int) (int, error) {
+	s.place(i, p)
+	defer s.remove(i, p)
+
+	switch {
+	case s.board.wins(i):
+		return winScore - 1, nil
+	case s.board.isFull():
+		return 0, nil
+	}
+	score, err := s.search(p.Opponent(), child(beta), child(alpha))
+	return parent(score), err
+}
+
+// search is a negamax search with alpha-beta pruning. The scores it
+// returns are fail-soft bounds.
+func (s *Solver) search(toMove Player, alpha, beta int) (int, error) {
+	s.stats.Nodes += 1
+	if s.nodeLimit > 0 && s.stats.Nodes > s.nodeLimit {
+		return 0, ErrNodeLimit
+	}
+
+	key := s.key(toMove)
+	s.stats.Lookups += 1
+	if e, ok := s.table[key]; ok {
+		s.stats.Hits += 1
+		score := int(e.score)
+		switch {
+		case e.bound == exact,
+			e.bound == lower && score >= beta,
+			e.bound == upper && score <= alpha:
+			return score, nil
+		}
+	}
+
+	alphaOrig := alpha
+	best := -winScore
+	for _, i := range s.order {
+		if s.board.squares[i] != Empty {
+			continue
+		}
+		score, err := s.tryMove(i, toMove, alpha, beta)
+		if err != nil {
+			return 0, err
+		}
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	e := entry{score: int16(best), bound: exact}
+	switch {
+	case best <= alphaOrig:
+		e.bound = upper
+	case best >= beta:
+		e.bound = lower
+	}
+	if len(s.table) >= s.tableSize {
+		s.table = make(map[uint64]entry)
+	}
+	s.table[key] = e
+	return best, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
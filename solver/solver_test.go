@@ -0,0 +1,210 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package solver
+
+import "testing"
+
+// bruteForce returns the result of the position for the player to move by
+// searching every line of play. Results are remembered in memo.
+func bruteForce(b *Board, toMove Player, memo map[string]Result) Result {
+	key := positionKey(b, toMove)
+	if r, ok := memo[key]; ok {
+		return r
+	}
+	best := Result{Outcome: Loss}
+	found := false
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			if b.Get(x, y) != Empty {
+				continue
+			}
+			r := moveResult(b, x, y, toMove, memo)
+			if !found || better(r, best) {
+				best, found = r, true
+			}
+		}
+	}
+	memo[key] = best
+	return best
+}
+
+func positionKey(b *Board, toMove Player) string {
+	key := make([]byte, 0, len(b.squares)+1)
+	for _, p := range b.squares {
+		key = append(key, byte(p))
+	}
+	return string(append(key, byte(toMove)))
+}
+
+// moveResult returns the result of the move at (x, y) for the player
+// making it.
+func moveResult(b *Board, x, y int, p Player, memo map[string]Result) Result {
+	b.Set(x, y, p)
+	defer b.Set(x, y, Empty)
+	switch {
+	case b.Winner() == p:
+		return Result{Outcome: Win, Distance: 1}
+	case b.isFull():
+		return Result{Outcome: Draw}
+	}
+	r := bruteForce(b, p.Opponent(), memo)
+	switch r.Outcome {
+	case Win:
+		return Result{Outcome: Loss, Distance: r.Distance + 1}
+	case Loss:
+		return Result{Outcome: Win, Distance: r.Distance + 1}
+	}
+	return Result{Outcome: Draw}
+}
+
+// better reports whether a is better than b for the player getting them.
+// Faster wins and slower losses are better.
+func better(a, b Result) bool {
+	if a.Outcome != b.Outcome {
+		return a.Outcome > b.Outcome
+	}
+	switch a.Outcome {
+	case Win:
+		return a.Distance < b.Distance
+	case Loss:
+		return a.Distance > b.Distance
+	}
+	return false
+}
+
+func TestSolveEmptyBoard(t *testing.T) {
+	tests := []struct {
+		width, height, winLength int
+		result                   Result
+	}{
+		{3, 3, 3, Result{Outcome: Draw}},
+		{4, 3, 3, Result{Outcome: Win, Distance: 7}},
+		{4, 4, 3, Result{Outcome: Win, Distance: 5}},
+		{4, 4, 4, Result{Outcome: Draw}},
+	}
+	for _, test := range tests {
+		s, err := New(test.width, test.height, test.winLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := NewBoard(test.width, test.height, test.winLength)
+		r, err := s.Solve(b, First)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != test.result {
+			t.Errorf("%dx%d k%d: got %+v, expected %+v", test.width, test.height, test.winLength, r, test.result)
+		}
+	}
+}
+
+// TestSolveMatchesBruteForce compares the solver with a plain search on
+// every undecided position of a 3x3 board. The small table makes the
+// solver clear it while it searches.
+func TestSolveMatchesBruteForce(t *testing.T) {
+	s, err := New(3, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetTableSize(64)
+	b, _ := NewBoard(3, 3, 3)
+	memo := make(map[string]Result)
+	seen := make(map[string]bool)
+	var walk func(toMove Player)
+	walk = func(toMove Player) {
+		if b.Winner() != Empty || b.isFull() || seen[positionKey(b, toMove)] {
+			return
+		}
+		seen[positionKey(b, toMove)] = true
+		expected := bruteForce(b, toMove, memo)
+		r, err := s.Solve(b, toMove)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != expected {
+			t.Fatalf("position %v with %d to move: got %+v, expected %+v", b.squares, toMove, r, expected)
+		}
+		for i := range b.squares {
+			if b.squares[i] != Empty {
+				continue
+			}
+			b.set(i, toMove)
+			walk(toMove.Opponent())
+			b.set(i, Empty)
+		}
+	}
+	walk(First)
+	// 3x3 tic-tac-toe has 4520 undecided positions.
+	if len(seen) != 4520 {
+		t.Errorf("got %d positions, expected 4520", len(seen))
+	}
+}
+
+func TestMovesForcedWin(t *testing.T) {
+	// X to move wins at once by completing the top row at (2, 0) or the
+	// left column at (0, 1).
+	b, _ := NewBoard(3, 3, 3)
+	b.Set(0, 0, First)
+	b.Set(1, 0, First)
+	b.Set(1, 1, Second)
+	b.Set(1, 2, Second)
+	b.Set(0, 2, First)
+	b.Set(2, 1, Second)
+	s, _ := New(3, 3, 3)
+	moves, err := s.Moves(b, First)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		expected := moveResult(b, m.X, m.Y, First, make(map[string]Result))
+		if m.Result != expected {
+			t.Errorf("move (%d, %d): got %+v, expected %+v", m.X, m.Y, m.Result, expected)
+		}
+		win := m.X == 2 && m.Y == 0 || m.X == 0 && m.Y == 1
+		if win && m.Result != (Result{Outcome: Win, Distance: 1}) {
+			t.Errorf("move (%d, %d): got %+v, expected an immediate win", m.X, m.Y, m.Result)
+		}
+	}
+}
+
+func TestNodeLimit(t *testing.T) {
+	s, _ := New(4, 4, 4)
+	s.SetNodeLimit(1000)
+	b, _ := NewBoard(4, 4, 4)
+	if _, err := s.Solve(b, First); err != ErrNodeLimit {
+		t.Fatalf("got %v, expected %v", err, ErrNodeLimit)
+	}
+}
+
+func benchmarkSolve(b *testing.B, width, height, winLength int) {
+	var stats Stats
+	for i := 0; i < b.N; i++ {
+		s, err := New(width, height, winLength)
+		if err != nil {
+			b.Fatal(err)
+		}
+		board, _ := NewBoard(width, height, winLength)
+		if _, err := s.Solve(board, First); err != nil {
+			b.Fatal(err)
+		}
+		stats = s.Stats()
+	}
+	b.Logf("%d nodes, %d lookups, hit rate %.3f", stats.Nodes, stats.Lookups, stats.HitRate())
+}
+
+func BenchmarkSolve3x3(b *testing.B)   { benchmarkSolve(b, 3, 3, 3) }
+func BenchmarkSolve4x4k3(b *testing.B) { benchmarkSolve(b, 4, 4, 3) }
+func BenchmarkSolve4x4k4(b *testing.B) { benchmarkSolve(b, 4, 4, 4) }
@@ -0,0 +1,224 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc/codes"
+	"github.com/protogalaxy/service-tictactoe-game/solver"
+)
+
+var ErrInvalidBoard = errors.New("invalid board")
+
+const (
+	// analyzeMaxGridSize is the longest side of a board that is analyzed.
+	analyzeMaxGridSize = 15
+	// analyzeTimeLimit is how long the moves of a position are searched.
+	// Moves that were not searched in time are UNKNOWN.
+	analyzeTimeLimit = 2 * time.Second
+)
+
+// Players of a board that is analyzed without a game.
+const (
+	boardPlayerX = "X"
+	boardPlayerY = "Y"
+)
+
+// boardGame builds a game from a supplied board.
+func boardGame(req *AnalyzeRequest) (*game, error) {
+	g, err := newGame("", req.Variant, []string{boardPlayerX, boardPlayerY}, int(req.Width), int(req.Height), int(req.WinLength))
+	if err != nil {
+		return nil, err
+	}
+	rules, ok := g.rules().(BoardRuleset)
+	if !ok || len(req.Board) != len(g.Grid.grid) {
+		return nil, ErrInvalidBoard
+	}
+	marks := make(map[Mark]bool)
+	for _, mark := range g.Players {
+		marks[mark] = true
+	}
+	for i, mark := range req.Board {
+		if mark != Mark_EMPTY && !marks[mark] {
+			return nil, ErrInvalidBoard
+		}
+		g.Grid.grid[i] = mark
+		if mark != Mark_EMPTY {
+			g.TurnNumber += 1
+		}
+	}
+	if err := rules.SetupBoard(g); err != nil {
+		return nil, err
+	}
+	if req.ToMove != Mark_EMPTY && req.ToMove != Mark_X+Mark(g.CurrentPlayer) {
+		return nil, ErrInvalidBoard
+	}
+
+	// A board is decided if a line goes through one of its marks. The line
+	// was completed by the player with its mark or, if both players use
+	// the same mark, by the player that moved last.
+	shared := len(marks) == 1
+	for i, mark := range g.Grid.grid {
+		if mark == Mark_EMPTY {
+			continue
+		}
+		x, y := i%g.Grid.width, i/g.Grid.width
+		userID := g.PlayerList[mark-Mark_X]
+		if shared {
+			userID = g.PlayerList[1-g.CurrentPlayer]
+		}
+		if w := rules.Outcome(g, userID, x, y); w != nil {
+			g.Winner = w
+			break
+		}
+	}
+	return g, nil
+}
+
+// analyze scores every legal move of the active player. Positions of the
+// standard variant are solved if the solver finishes within its node
+// limit. Otherwise small boards are searched to the end and on larger
+// boards moves without a forced result within the search depth are
+// UNKNOWN. The most promising moves are searched first and moves that are
+// left when the time limit is reached are UNKNOWN as well. With more than
+// two players in play all moves are UNKNOWN and the best move is found by
+// Monte Carlo tree search.
+func analyze(g *game) ([]*MoveAnalysis, *TurnRequest_Square, solver.Stats) {
+	squares := legalSquares(g)
+	if len(g.playersInPlay()) > 2 {
+		moves := make([]*MoveAnalysis, 0, len(squares))
+		for _, sq := range squares {
+			moves = append(moves, &MoveAnalysis{Square: g.squareAt(sq[0], sq[1])})
+		}
+		x, y := mctsStrategy{iterations: mctsDefaultIterations}.Move(g, g.activePlayer())
+		return moves, g.squareAt(x, y), solver.Stats{}
+	}
+	order := searchOrder(g, squares)
+	known := make([]bool, len(squares))
+	scores, stats, exact := solvedScores(g, squares)
+	if exact {
+		for i := range known {
+			known[i] = true
+		}
+	} else {
+		scores = make([]int, len(squares))
+		var depth int
+		depth, exact = searchDepth(g)
+		userID := g.activePlayer()
+		budget := newSearchBudget(analyzeTimeLimit)
+		for _, i := range order {
+			c := g.searchCopy()
+			c.applyMove(userID, squares[i][0], squares[i][1])
+			score := -negamax(c, depth-1, -minimaxWinScore-1, minimaxWinScore+1, 1, budget)
+			if budget.exhausted() {
+				break
+			}
+			scores[i], known[i] = score, true
+		}
+	}
+
+	moves := make([]*MoveAnalysis, 0, len(squares))
+	best, bestScore := squares[order[0]], -minimaxWinScore-1
+	for i, sq := range squares {
+		score := scores[i]
+		a := &MoveAnalysis{Square: g.squareAt(sq[0], sq[1])}
+		moves = append(moves, a)
+		if !known[i] {
+			continue
+		}
+		switch {
+		case score > minimaxWinScore/2:
+			a.Result = MoveAnalysis_WIN
+			a.Distance = int32(minimaxWinScore - score)
+		case score < -minimaxWinScore/2:
+			a.Result = MoveAnalysis_LOSS
+			a.Distance = int32(minimaxWinScore + score)
+		case exact:
+			a.Result = MoveAnalysis_DRAW
+		}
+		if score > bestScore || score == bestScore && centerDistance(g.Grid, sq) < centerDistance(g.Grid, best) {
+			best, bestScore = sq, score
+		}
+	}
+	return moves, g.squareAt(best[0], best[1]), stats
+}
+
+// searchOrder returns the indexes of the squares with the squares a bot
+// would search first at the front.
+func searchOrder(g *game, squares [][2]int) []int {
+	index := make(map[[2]int]int, len(squares))
+	for i, sq := range squares {
+		index[sq] = i
+	}
+	order := make([]int, 0, len(squares))
+	for _, sq := range searchSquares(g) {
+		order = append(order, index[sq])
+		delete(index, sq)
+	}
+	for i, sq := range squares {
+		if _, ok := index[sq]; ok {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+func (m *GameManager) Analyze(ctx context.Context, req *AnalyzeRequest) (*AnalyzeReply, error) {
+	var g *game
+	if req.GameId != "" {
+		entry, ok := m.games.get(GameID(req.GameId))
+		if !ok {
+			return &AnalyzeReply{Status: AnalyzeReply_GAME_NOT_FOUND}, nil
+		}
+		entry.lock.Lock()
+		if entry.game.Expired {
+			entry.lock.Unlock()
+			return &AnalyzeReply{Status: AnalyzeReply_GAME_NOT_FOUND}, nil
+		}
+		g = entry.game.clone()
+		entry.lock.Unlock()
+
+		if req.MovesBack > 0 {
+			if err := g.takeBack(int(req.MovesBack)); err != nil {
+				return nil, grpc.Errorf(codes.InvalidArgument, "%s", err)
+			}
+		}
+	} else {
+		var err error
+		g, err = boardGame(req)
+		if err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%s", err)
+		}
+	}
+
+	if g.Grid.width > analyzeMaxGridSize || g.Grid.height > analyzeMaxGridSize {
+		return nil, grpc.Errorf(codes.InvalidArgument, "boards larger than %d squares on a side are not analyzed", analyzeMaxGridSize)
+	}
+	if g.isFinished() {
+		return &AnalyzeReply{Status: AnalyzeReply_FINISHED}, nil
+	}
+	moves, best, stats := analyze(g)
+	return &AnalyzeReply{
+		Moves:        moves,
+		BestMove:     best,
+		Nodes:        stats.Nodes,
+		CacheHitRate: stats.HitRate(),
+	}, nil
+}
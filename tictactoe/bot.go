@@ -0,0 +1,230 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
+)
+
+// BotPrefix marks user ids that are played by the service itself.
+const BotPrefix = "bot:"
+
+// retryDelay is the time after which a bot move or a time out that could
+// not be committed is tried again.
+const retryDelay = time.Second
+
+var (
+	ErrUnknownBot         = errors.New("unknown bot")
+	ErrUnknownStrategy    = errors.New("unknown bot strategy")
+	ErrInvalidBotSettings = errors.New("invalid bot settings")
+)
+
+// Strategy chooses the moves of a bot. Move is only called while the game
+// is running and must return a valid move for the active player.
+type Strategy interface {
+	Move(g *game, userID string) (x, y int)
+}
+
+// StrategyFactory creates a strategy from the settings of a bot seat.
+type StrategyFactory func(seat *BotSeat) (Strategy, error)
+
+var strategies = make(map[string]StrategyFactory)
+
+// RegisterStrategy makes a bot strategy available under the given name. It
+// panics if a strategy is registered twice under the same name.
+func RegisterStrategy(name string, f StrategyFactory) {
+	if _, dup := strategies[name]; dup {
+		panic(fmt.Sprintf("tictactoe: strategy %q registered twice", name))
+	}
+	strategies[name] = f
+}
+
+func newStrategy(seat *BotSeat) (Strategy, error) {
+	f, ok := strategies[seat.Strategy]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return f(seat)
+}
+
+// defaultBots are the bots that can be seated without settings.
+var defaultBots = map[string]BotSeat{
+	BotPrefix + "random":  {Strategy: "random"},
+	BotPrefix + "greedy":  {Strategy: "greedy"},
+	BotPrefix + "easy":    {Strategy: "minimax", Depth: 2, ErrorRate: 0.3},
+	BotPrefix + "medium":  {Strategy: "minimax", Depth: 4, ErrorRate: 0.1},
+	BotPrefix + "perfect": {Strategy: "minimax"},
+	BotPrefix + "mcts":    {Strategy: "mcts"},
+}
+
+func isBot(userID string) bool {
+	return strings.HasPrefix(userID, BotPrefix)
+}
+
+// setBots stores the settings of every bot of the game. Bots without
+// settings must be default bots.
+func (g *game) setBots(seats []*BotSeat) error {
+	bots := make(map[string]*BotSeat)
+	for _, seat := range seats {
+		if _, ok := g.Players[seat.UserId]; !ok || !isBot(seat.UserId) {
+			return ErrInvalidBotSettings
+		}
+		s := *seat
+		bots[seat.UserId] = &s
+	}
+	for _, userID := range g.PlayerList {
+		if !isBot(userID) {
+			continue
+		}
+		if _, ok := bots[userID]; !ok {
+			seat, ok := defaultBots[userID]
+			if !ok {
+				return ErrUnknownBot
+			}
+			seat.UserId = userID
+			bots[userID] = &seat
+		}
+		if _, err := newStrategy(bots[userID]); err != nil {
+			return err
+		}
+	}
+	if len(bots) > 0 {
+		g.Bots = bots
+	}
+	return nil
+}
+
+// botSeats returns the settings of the bots in turn order.
+func (g *game) botSeats() []*BotSeat {
+	var seats []*BotSeat
+	for _, userID := range g.PlayerList {
+		if seat, ok := g.Bots[userID]; ok {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+// playBot plays the turn of the bot that is active in the game. The bot
+// thinks on a copy of the game without holding the lock of the game, so
+// the game can change in the meantime. The move is only played if it is
+// still the same turn. A bot that runs out of time while thinking times
+// out like any other player.
+func (m *GameManager) playBot(id GameID) {
+	entry, ok := m.games.get(id)
+	if !ok {
+		return
+	}
+	entry.lock.Lock()
+	if m.stopped() || entry.game.Expired || m.timeouts.isHeld() {
+		entry.lock.Unlock()
+		return
+	}
+	userID := entry.game.activePlayer()
+	seat, ok := entry.game.Bots[userID]
+	if !ok {
+		entry.lock.Unlock()
+		return
+	}
+	moveID := entry.game.lastMoveID()
+	c := entry.game.searchCopy()
+	entry.lock.Unlock()
+
+	s, err := newStrategy(seat)
+	if err != nil {
+		glog.Errorf("Bot %s of game %s: %s", userID, id, err)
+		return
+	}
+	x, y := s.Move(c, userID)
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	if m.stopped() || entry.game.Expired || entry.game.activePlayer() != userID || entry.game.lastMoveID() != moveID {
+		return
+	}
+	g := entry.game.clone()
+	now := time.Now().UnixNano()
+	var ev *Event
+	if loser, ok := g.checkTimeout(now); ok {
+		ev = timeoutEvent(g, loser, now)
+	} else if err := g.placeMark(userID, moveID, x, y); err != nil {
+		glog.Errorf("Bot %s of game %s played an invalid move: %s", userID, id, err)
+		return
+	} else {
+		ev = turnEvent(g, userID, g.lastSquare(), TurnReply_SUCCESS)
+	}
+	if err := m.commit(entry, g, ev); err != nil {
+		glog.Errorf("Playing bot %s of game %s: %s", userID, id, err)
+		m.timeouts.schedule(id, now+int64(retryDelay), func() {
+			m.playBot(id)
+		})
+	}
+}
+
+// searchCopy is a cheap copy of the game for trying moves. It shares
+// everything the rules do not change.
+func (g *game) searchCopy() *game {
+	c := *g
+	c.Grid = g.Grid.clone()
+	c.Moves = nil
+	c.Outbox = nil
+	if g.SubBoards != nil {
+		c.SubBoards = make([]*Winner, len(g.SubBoards))
+		copy(c.SubBoards, g.SubBoards)
+	}
+	return &c
+}
+
+// legalSquares lists the squares covered by the valid moves of the game.
+func legalSquares(g *game) [][2]int {
+	var squares [][2]int
+	for _, r := range g.validMoves() {
+		// Ranges on a sub-board are relative to it.
+		var dx, dy int
+		if r.Board != nil {
+			dx, dy = int(r.Board.X)*subBoardSize, int(r.Board.Y)*subBoardSize
+		}
+		if r.ToX == 0 && r.ToY == 0 {
+			squares = append(squares, [2]int{dx + int(r.FromX), dy + int(r.FromY)})
+			continue
+		}
+		for x := int(r.FromX); x <= int(r.ToX); x++ {
+			for y := int(r.FromY); y <= int(r.ToY); y++ {
+				squares = append(squares, [2]int{dx + x, dy + y})
+			}
+		}
+	}
+	return squares
+}
+
+// centerDistance returns twice the Chebyshev distance of the square from
+// the center of the grid.
+func centerDistance(grid *gameGrid, sq [2]int) int {
+	dx := 2*sq[0] - (grid.width - 1)
+	dy := 2*sq[1] - (grid.height - 1)
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return maxInt(dx, dy)
+}
@@ -0,0 +1,282 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
+)
+
+var ErrInvalidTimeControl = errors.New("invalid time control")
+
+func (g *game) setTimeControl(tc *TimeControl) error {
+	if tc == nil {
+		return nil
+	}
+	if tc.MoveLimitMs < 0 || tc.InitialMs < 0 || tc.IncrementMs < 0 {
+		return ErrInvalidTimeControl
+	}
+	if tc.MoveLimitMs == 0 && tc.InitialMs == 0 {
+		// An increment alone would not limit anything.
+		if tc.IncrementMs != 0 {
+			return ErrInvalidTimeControl
+		}
+		return nil
+	}
+	g.TimeControl = tc
+	if tc.InitialMs > 0 {
+		g.Clocks = make(map[string]int64, len(g.PlayerList))
+		for _, userID := range g.PlayerList {
+			g.Clocks[userID] = tc.InitialMs * int64(time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// deadline returns the time at which the active player runs out of time or
+// zero if the turn is not limited.
+func (g *game) deadline() int64 {
+	if g.TimeControl == nil || g.isFinished() {
+		return 0
+	}
+	start := g.lastActivity()
+	var d int64
+	if g.TimeControl.MoveLimitMs > 0 {
+		d = start + g.TimeControl.MoveLimitMs*int64(time.Millisecond)
+	}
+	if g.Clocks != nil {
+		c := start + g.Clocks[g.activePlayer()]
+		if d == 0 || c < d {
+			d = c
+		}
+	}
+	return d
+}
+
+// chargeClock subtracts the time the player took for the turn from their
+// clock and adds the increment.
+func (g *game) chargeClock(userID string, now int64) {
+	if g.Clocks == nil {
+		return
+	}
+	g.Clocks[userID] -= now - g.lastActivity()
+	g.Clocks[userID] += g.TimeControl.IncrementMs * int64(time.Millisecond)
+}
+
+// checkTimeout makes the active player leave the game if they ran out of
+// time and returns them.
+func (g *game) checkTimeout(now int64) (string, bool) {
+	d := g.deadline()
+	if d == 0 || now < d {
+		return "", false
+	}
+	loser := g.activePlayer()
+	if g.Clocks != nil {
+		g.Clocks[loser] -= now - g.lastActivity()
+		if g.Clocks[loser] < 0 {
+			g.Clocks[loser] = 0
+		}
+	}
+	g.leave(loser, Winner_TIMEOUT, now)
+	return loser, true
+}
+
+// clocks returns the remaining time of every player in turn order.
+func (g *game) clocks() []*Clock {
+	if g.Clocks == nil {
+		return nil
+	}
+	clocks := make([]*Clock, 0, len(g.PlayerList))
+	for _, userID := range g.PlayerList {
+		clocks = append(clocks, &Clock{
+			UserId:      userID,
+			RemainingMs: g.Clocks[userID] / int64(time.Millisecond),
+		})
+	}
+	return clocks
+}
+
+// timeouts keeps a timer for every game whose active player is on the
+// clock. While the timeouts are held the deadlines are only remembered,
+// for example so that a replay can bring games up to date before they
+// time out.
+type timeouts struct {
+	lock    sync.Mutex
+	held    bool
+	stopped bool
+	pending map[GameID]*timeout
+}
+
+type timeout struct {
+	deadline int64
+	fn       func()
+	timer    *time.Timer
+}
+
+func newTimeouts() *timeouts {
+	return &timeouts{
+		pending: make(map[GameID]*timeout),
+	}
+}
+
+func (t *timeout) start() {
+	t.timer = time.AfterFunc(time.Duration(t.deadline-time.Now().UnixNano()), t.fn)
+}
+
+func (t *timeout) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// schedule calls fn at the deadline, replacing the timeout the game had
+// before. A zero deadline only cancels the previous timeout.
+func (t *timeouts) schedule(id GameID, deadline int64, fn func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if old, ok := t.pending[id]; ok {
+		old.stop()
+		delete(t.pending, id)
+	}
+	if deadline == 0 || t.stopped {
+		return
+	}
+	to := &timeout{deadline: deadline, fn: fn}
+	if !t.held {
+		to.start()
+	}
+	t.pending[id] = to
+}
+
+func (t *timeouts) cancel(id GameID) {
+	t.schedule(id, 0, nil)
+}
+
+func (t *timeouts) hold() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.held = true
+	for _, to := range t.pending {
+		to.stop()
+	}
+}
+
+func (t *timeouts) release() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.held = false
+	for _, to := range t.pending {
+		to.start()
+	}
+}
+
+func (t *timeouts) isHeld() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.held
+}
+
+// stop cancels all timeouts. No timeouts are scheduled afterwards.
+func (t *timeouts) stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.stopped = true
+	for id, to := range t.pending {
+		to.stop()
+		delete(t.pending, id)
+	}
+}
+
+// HoldTimeouts keeps games from timing out and bots from moving until
+// ReleaseTimeouts is called, so games can be brought up to date first.
+func (m *GameManager) HoldTimeouts() {
+	m.timeouts.hold()
+}
+
+// ReleaseTimeouts times out the games whose deadline passed while the
+// timeouts were held and lets bots move again.
+func (m *GameManager) ReleaseTimeouts() {
+	m.timeouts.release()
+}
+
+// scheduleTimeout starts the timer of the active player. A bot that is
+// active gets its turn right away.
+func (m *GameManager) scheduleTimeout(g *game) {
+	id := g.ID
+	if _, ok := g.Bots[g.activePlayer()]; ok && !g.Expired {
+		m.timeouts.schedule(id, time.Now().UnixNano(), func() {
+			m.playBot(id)
+		})
+		return
+	}
+	m.timeouts.schedule(id, g.deadline(), func() {
+		m.timeOut(id)
+	})
+}
+
+func (m *GameManager) timeOut(id GameID) {
+	if m.stopped() {
+		return
+	}
+	entry, ok := m.games.get(id)
+	if !ok {
+		return
+	}
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	// A held timeout is started again when it is released.
+	if entry.game.Expired || m.timeouts.isHeld() {
+		return
+	}
+	g := entry.game.clone()
+	now := time.Now().UnixNano()
+	loser, ok := g.checkTimeout(now)
+	if !ok {
+		m.scheduleTimeout(entry.game)
+		return
+	}
+	if err := m.commit(entry, g, timeoutEvent(g, loser, now)); err != nil {
+		glog.Errorf("Timing out game %s: %s", id, err)
+		m.timeouts.schedule(id, now+int64(retryDelay), func() {
+			m.timeOut(id)
+		})
+	}
+}
+
+// timeoutEvent builds the event of a player running out of time. Without
+// a winner the player was eliminated and the game goes on.
+func timeoutEvent(g *game, loser string, now int64) *Event {
+	return &Event{
+		Type:       Event_GAME_TIMED_OUT,
+		Timestamp:  now,
+		GameId:     string(g.ID),
+		UserId:     loser,
+		UserList:   g.PlayerList,
+		MoveId:     g.lastMoveID(),
+		NextPlayer: g.activePlayer(),
+		Winner:     g.winner(),
+		Clocks:     g.clocks(),
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+var (
+	ErrDrawAlreadyOffered = errors.New("draw already offered")
+	ErrNoDrawOffer        = errors.New("no draw offer to respond to")
+)
+
+func (g *game) offerDraw(userID string) error {
+	if err := g.checkPlayer(userID); err != nil {
+		return err
+	}
+	if len(g.playersInPlay()) > 2 {
+		return ErrTooManyPlayers
+	}
+	if g.DrawOffer != "" {
+		return ErrDrawAlreadyOffered
+	}
+	g.DrawOffer = userID
+	return nil
+}
+
+// respondToDraw accepts or declines the draw offer of the opponent.
+func (g *game) respondToDraw(userID string, accept bool) error {
+	if err := g.checkPlayer(userID); err != nil {
+		return err
+	}
+	if g.DrawOffer == "" || g.DrawOffer == userID {
+		return ErrNoDrawOffer
+	}
+	if accept {
+		g.finish(&Winner{
+			Draw:   true,
+			Reason: Winner_AGREEMENT,
+		})
+	} else {
+		g.DrawOffer = ""
+	}
+	return nil
+}
+
+func (m *GameManager) OfferDraw(ctx context.Context, req *OfferDrawRequest) (*OfferDrawReply, error) {
+	var rep OfferDrawReply
+	err := m.playerAction(req.GameId, req.UserId, Event_DRAW_OFFERED, func(g *game, ev *Event) error {
+		return g.offerDraw(req.UserId)
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = OfferDrawReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = OfferDrawReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = OfferDrawReply_FINISHED
+	case err == ErrDrawAlreadyOffered:
+		rep.Status = OfferDrawReply_ALREADY_OFFERED
+	case err == ErrTooManyPlayers:
+		rep.Status = OfferDrawReply_TOO_MANY_PLAYERS
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (m *GameManager) RespondToDraw(ctx context.Context, req *RespondToDrawRequest) (*RespondToDrawReply, error) {
+	t := Event_DRAW_DECLINED
+	if req.Accept {
+		t = Event_DRAW_ACCEPTED
+	}
+	var rep RespondToDrawReply
+	err := m.playerAction(req.GameId, req.UserId, t, func(g *game, ev *Event) error {
+		return g.respondToDraw(req.UserId, req.Accept)
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = RespondToDrawReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = RespondToDrawReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = RespondToDrawReply_FINISHED
+	case err == ErrNoDrawOffer:
+		rep.Status = RespondToDrawReply_NO_OFFER
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
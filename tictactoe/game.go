@@ -22,27 +22,91 @@ import (
 
 type GameID string
 
+// MaxPlayers is the largest number of players a game can have. The players
+// get the marks X, Y, Z and W in turn order.
+const MaxPlayers = 4
+
 type game struct {
 	ID            GameID
+	Variant       string
 	Grid          *gameGrid
+	WinLength     int
 	CurrentPlayer int
 	PlayerList    []string
 	Players       map[string]Mark
 	Winner        *Winner
 	TurnNumber    int
 	TurnTimestamp int64
+	Sequence      int64
+	// Outbox holds the events that were not published yet. It is stored
+	// apart from the game, but games saved by older versions include it.
+	Outbox []*Event `json:",omitempty"`
+
+	// Moves lists the squares marked by the moves of the game in the order
+	// they were played.
+	Moves []playedMove
+	// Eliminated lists the players that left the game while the others
+	// played on, in order.
+	Eliminated []string
+
+	// SubBoards holds the results of the sub-boards of an ultimate game in
+	// row-major order. NextBoard is the index of the sub-board the active
+	// player has to play in or anyBoard.
+	SubBoards []*Winner
+	NextBoard int
+
+	CreatedTimestamp  int64
+	FinishedTimestamp int64
+	Expired           bool
+
+	// DrawOffer is the player with a pending draw offer.
+	DrawOffer string
+	Takeback  *takebackRequest
+
+	// The game is game number GameNumber of the series. SeriesScore is
+	// the result of the games before it.
+	SeriesID    string
+	GameNumber  int
+	SeriesScore seriesScore
+	RematchID   GameID
+
+	// Bots holds the settings of the players that are bots.
+	Bots map[string]*BotSeat
+
+	TimeControl *TimeControl
+	// Clocks holds the remaining time of every player in nanoseconds at
+	// the start of the current turn.
+	Clocks map[string]int64
 }
 
-func newGame(ID GameID, playerOne, playerTwo string) *game {
-	return &game{
-		ID:         ID,
-		Grid:       newGameGrid(),
-		PlayerList: []string{playerOne, playerTwo},
-		Players: map[string]Mark{
-			playerOne: Mark_X,
-			playerTwo: Mark_Y,
-		},
+func newGame(ID GameID, variant string, players []string, width, height, winLength int) (*game, error) {
+	if variant == "" {
+		variant = DefaultVariant
 	}
+	rules, err := lookupRuleset(variant)
+	if err != nil {
+		return nil, err
+	}
+	g := &game{
+		ID:               ID,
+		Variant:          variant,
+		PlayerList:       make([]string, len(players)),
+		Players:          make(map[string]Mark, len(players)),
+		CreatedTimestamp: time.Now().UnixNano(),
+	}
+	copy(g.PlayerList, players)
+	for i, userID := range players {
+		g.Players[userID] = Mark_X + Mark(i)
+	}
+	if err := rules.Setup(g, width, height, winLength); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+type playedMove struct {
+	UserID string
+	X, Y   int
 }
 
 var (
@@ -58,49 +122,115 @@ func (g *game) activePlayer() string {
 	return g.PlayerList[g.CurrentPlayer]
 }
 
+// updateActivePlayer passes the turn to the next player that was not
+// eliminated.
 func (g *game) updateActivePlayer() {
-	g.CurrentPlayer = (g.CurrentPlayer + 1) % len(g.PlayerList)
+	for range g.PlayerList {
+		g.CurrentPlayer = (g.CurrentPlayer + 1) % len(g.PlayerList)
+		if !g.isEliminated(g.PlayerList[g.CurrentPlayer]) {
+			return
+		}
+	}
 }
 
-func (g *game) checkWinner(userID string, x, y int) {
-	w := &Winner{}
-	var noWinner bool
-	if g.Grid.checkMarksHorizontal(y) {
-		w.Locations = append(w.Locations, &Winner_Location{
-			Direction: Winner_Location_HORIZONTAL,
-			Position:  int32(y),
-		})
-		w.UserId = userID
-	} else if g.Grid.checkMarksVertical(x) {
-		w.Locations = append(w.Locations, &Winner_Location{
-			Direction: Winner_Location_VERTICAL,
-			Position:  int32(x),
-		})
-		w.UserId = userID
-	} else if g.Grid.checkDiagonalUp() {
-		w.Locations = append(w.Locations, &Winner_Location{
-			Direction: Winner_Location_DIAGONAL_UP,
-		})
-		w.UserId = userID
-	} else if g.Grid.checkDiagonalDown() {
-		w.Locations = append(w.Locations, &Winner_Location{
-			Direction: Winner_Location_DIAGONAL_DOWN,
-		})
-		w.UserId = userID
-	} else if g.Grid.isFull() {
-		w.Draw = true
-	} else {
-		noWinner = true
+func (g *game) isEliminated(userID string) bool {
+	for _, id := range g.Eliminated {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// playersInPlay returns the players that were not eliminated in turn
+// order.
+func (g *game) playersInPlay() []string {
+	players := make([]string, 0, len(g.PlayerList))
+	for _, userID := range g.PlayerList {
+		if !g.isEliminated(userID) {
+			players = append(players, userID)
+		}
+	}
+	return players
+}
+
+// clone returns a deep copy of the game. Events and the winner are shared
+// since they are never modified once created.
+func (g *game) clone() *game {
+	c := *g
+	c.Grid = g.Grid.clone()
+	c.PlayerList = make([]string, len(g.PlayerList))
+	copy(c.PlayerList, g.PlayerList)
+	c.Players = make(map[string]Mark, len(g.Players))
+	for userID, mark := range g.Players {
+		c.Players[userID] = mark
+	}
+	c.Outbox = make([]*Event, len(g.Outbox))
+	copy(c.Outbox, g.Outbox)
+	c.Moves = make([]playedMove, len(g.Moves))
+	copy(c.Moves, g.Moves)
+	if g.SubBoards != nil {
+		c.SubBoards = make([]*Winner, len(g.SubBoards))
+		copy(c.SubBoards, g.SubBoards)
+	}
+	if g.Eliminated != nil {
+		c.Eliminated = make([]string, len(g.Eliminated))
+		copy(c.Eliminated, g.Eliminated)
+	}
+	if g.Clocks != nil {
+		c.Clocks = make(map[string]int64, len(g.Clocks))
+		for userID, remaining := range g.Clocks {
+			c.Clocks[userID] = remaining
+		}
 	}
-	if !noWinner {
-		g.Winner = w
+	return &c
+}
+
+func (g *game) rules() Ruleset {
+	r, err := lookupRuleset(g.Variant)
+	if err != nil {
+		panic(err)
 	}
+	return r
 }
 
 func (g *game) isFinished() bool {
 	return g.Winner != nil
 }
 
+// finish ends the game. A draw is shared by the players that are still in
+// play. A win with two players in play is a loss of the other one.
+func (g *game) finish(w *Winner) {
+	var loser string
+	if w.UserId != "" && w.LoserUserId == "" && len(g.playersInPlay()) == 2 {
+		loser = g.opponent(w.UserId)
+	}
+	if len(g.Eliminated) > 0 || w.Draw && len(w.DrawUserIds) == 0 || loser != "" {
+		c := *w
+		if loser != "" {
+			c.LoserUserId = loser
+		}
+		c.Eliminated = make([]string, len(g.Eliminated))
+		copy(c.Eliminated, g.Eliminated)
+		if c.Draw {
+			c.DrawUserIds = g.playersInPlay()
+		}
+		w = &c
+	}
+	g.Winner = w
+	g.DrawOffer = ""
+	g.FinishedTimestamp = time.Now().UnixNano()
+}
+
+// lastActivity returns the time of the last move or the creation of the
+// game if no move was played yet.
+func (g *game) lastActivity() int64 {
+	if g.TurnTimestamp > g.CreatedTimestamp {
+		return g.TurnTimestamp
+	}
+	return g.CreatedTimestamp
+}
+
 func (g *game) isDraw() bool {
 	return g.Winner.Draw
 }
@@ -109,6 +239,49 @@ func (g *game) winner() *Winner {
 	return g.Winner
 }
 
+func (g *game) state() *GameState {
+	s := &GameState{
+		GameId:     string(g.ID),
+		Variant:    g.Variant,
+		Width:      int32(g.Grid.width),
+		Height:     int32(g.Grid.height),
+		WinLength:  int32(g.WinLength),
+		Board:      make([]Mark, len(g.Grid.grid)),
+		TurnNumber: int32(g.TurnNumber),
+		MoveId:     g.lastMoveID(),
+		NextPlayer: g.activePlayer(),
+		Winner:     g.winner(),
+
+		TimeControl: g.TimeControl,
+		Clocks:      g.clocks(),
+		Deadline:    g.deadline(),
+		DrawOffer:   g.DrawOffer,
+		Moves:       g.moves(),
+
+		SeriesId:      g.SeriesID,
+		GameNumber:    int32(g.GameNumber),
+		RematchGameId: string(g.RematchID),
+		Bots:          g.botSeats(),
+		Eliminated:    g.Eliminated,
+		SubBoards:     g.subBoardResults(),
+	}
+	if g.Takeback != nil {
+		s.TakebackRequestedBy = g.Takeback.UserID
+		s.TakebackPlies = int32(g.Takeback.Plies)
+	}
+	copy(s.Board, g.Grid.grid)
+	for _, userID := range g.PlayerList {
+		s.Players = append(s.Players, &GameState_Player{
+			UserId: userID,
+			Mark:   g.Players[userID],
+		})
+	}
+	if !g.isFinished() {
+		s.ValidMoves = g.validMoves()
+	}
+	return s
+}
+
 func (g *game) placeMark(userID string, moveID int64, x, y int) error {
 	if g.isFinished() {
 		return ErrInvalidMove
@@ -116,27 +289,59 @@ func (g *game) placeMark(userID string, moveID int64, x, y int) error {
 		return ErrNotActivePlayer
 	} else if moveID != g.lastMoveID() {
 		return ErrInvalidMoveID
-	} else if !g.Grid.coordinatesValid(x, y) || !g.Grid.isEmpty(x, y) {
-		return ErrInvalidMove
 	}
-	g.Grid.set(x, y, g.Players[userID])
-	g.checkWinner(userID, x, y)
+	now := time.Now().UnixNano()
+	if err := g.applyMove(userID, x, y); err != nil {
+		return err
+	}
+	g.chargeClock(userID, now)
+	if g.DrawOffer == userID {
+		g.DrawOffer = ""
+	}
+	g.Takeback = nil
+	g.TurnTimestamp = now
+	return nil
+}
+
+// applyMove plays the move according to the rules of the game and adds it
+// to the move list.
+func (g *game) applyMove(userID string, x, y int) error {
+	rules := g.rules()
+	if err := rules.ValidateMove(g, userID, x, y); err != nil {
+		return err
+	}
+	x, y = rules.ApplyMove(g, userID, x, y)
+	g.Moves = append(g.Moves, playedMove{UserID: userID, X: x, Y: y})
+	if w := rules.Outcome(g, userID, x, y); w != nil {
+		g.finish(w)
+	}
 	g.updateActivePlayer()
 	g.TurnNumber += 1
-	g.TurnTimestamp = time.Now().UnixNano()
 	return nil
 }
 
+// lastSquare returns the square that was marked by the last move.
+func (g *game) lastSquare() *TurnRequest_Square {
+	mv := g.Moves[len(g.Moves)-1]
+	return g.squareAt(mv.X, mv.Y)
+}
+
 func (g *game) lastMoveID() int64 {
 	t := g.TurnTimestamp / 1000000000
 	return (t << 16) | int64(g.TurnNumber)
 }
 
 func (g *game) validMoves() []*MoveRange {
-	occupied := g.Grid.clone()
+	return g.rules().LegalMoves(g)
+}
+
+// emptySquares covers all empty squares of the grid with as few move ranges
+// as possible.
+func emptySquares(grid *gameGrid) []*MoveRange {
+	occupied := grid.clone()
 	validMoves := make([]*MoveRange, 0)
-	for x := 0; x < GridSize; x++ {
-		for y := 0; y < GridSize; y++ {
+	for x := 0; x < grid.width; x++ {
+		for y := 0; y < grid.height; y++ {
 			if !occupied.isEmpty(x, y) {
 				continue
 			}
@@ -178,7 +383,7 @@ func findValidMoveRange(g *gameGrid, x, y int) *MoveRange {
 
 func searchValidVertical(g *gameGrid, posX, posY int) int {
 	y := posY
-	for ; y < GridSize; y++ {
+	for ; y < g.height; y++ {
 		if !g.isEmpty(posX, y) {
 			break
 		}
@@ -189,7 +394,7 @@ func searchValidVertical(g *gameGrid, posX, posY int) int {
 
 func extendHorizontally(g *gameGrid, posX, posY, endY int) int {
 	x := posX
-	for ; x < GridSize; x++ {
+	for ; x < g.width; x++ {
 		if !columnEmpty(g, x, posY, endY) {
 			break
 		}
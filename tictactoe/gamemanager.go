@@ -18,31 +18,153 @@
 package tictactoe
 
 import (
-	"errors"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/code.google.com/p/go-uuid/uuid"
-	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/Shopify/sarama"
-	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
-	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/protobuf/proto"
 	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc/codes"
 )
 
-const streamTopic = "tictactoe-game-events"
+var (
+	ErrGameNotFound     = grpc.Errorf(codes.NotFound, "game not found")
+	ErrInvalidPlayers   = grpc.Errorf(codes.InvalidArgument, "number of players must be between 2 and %d", MaxPlayers)
+	ErrEmptyUserID      = grpc.Errorf(codes.InvalidArgument, "user id must not be empty")
+	ErrDuplicateUserID  = grpc.Errorf(codes.InvalidArgument, "user ids must be unique")
+	ErrMissingMove      = grpc.Errorf(codes.InvalidArgument, "move must be set")
+	ErrInvalidTurnOrder = grpc.Errorf(codes.InvalidArgument, "unknown turn order")
+)
 
 type GameManager struct {
-	lock        sync.Mutex
-	activeGames map[GameID]*game
+	games    *gameRegistry
+	watchers *watchHub
+
+	events   EventPublisher
+	relay    *relay
+	store    GameStore
+	timeouts *timeouts
+	series   *seriesIndex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewGameManager(p EventPublisher, s GameStore) *GameManager {
+	m := &GameManager{
+		games:    newGameRegistry(),
+		watchers: newWatchHub(),
+		events:   p,
+		relay:    newRelay(),
+		store:    s,
+		timeouts: newTimeouts(),
+		series:   newSeriesIndex(),
+		stop:     make(chan struct{}),
+	}
+	m.startRelay()
+	return m
+}
+
+// LoadGames registers the games of the store. Their events that had not
+// been published yet are published again. Expired games whose events were
+// all published are deleted from the store, finished games are kept until
+// the janitor expires them.
+func (m *GameManager) LoadGames() error {
+	ids, err := m.store.List()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		b, err := m.store.Load(id)
+		if err != nil {
+			return err
+		}
+		g, err := decodeGame(b)
+		if err != nil {
+			return fmt.Errorf("decoding game %s: %s", id, err)
+		}
+		if err := m.loadOutbox(g); err != nil {
+			return fmt.Errorf("loading the outbox of game %s: %s", id, err)
+		}
+		if g.Expired && len(g.Outbox) == 0 {
+			if err := m.store.Delete(id); err != nil {
+				return err
+			}
+			continue
+		}
+		entry := m.games.add(g)
+		if len(g.Outbox) > 0 {
+			m.relay.notify(g.ID)
+		}
+		m.scheduleTimeout(g)
+		m.series.add(g)
+		entry.lock.Unlock()
+	}
+	return nil
+}
+
+// Close stops publishing events, the janitor and the turn timers. Events
+// that are still in the outbox of a game are not published.
+func (m *GameManager) Close() {
+	m.relay.close()
+	close(m.stop)
+	m.timeouts.stop()
+	m.wg.Wait()
+}
+
+func (m *GameManager) stopped() bool {
+	select {
+	case <-m.stop:
+		return true
+	default:
+		return false
+	}
+}
 
-	stream sarama.SyncProducer
+// commit records the events in the outbox of the game, saves the game and
+// makes it the current state of the entry. Operations work on a clone of
+// the game, so nothing changes if saving fails. It must be called with the
+// lock of the entry held.
+func (m *GameManager) commit(entry *gameEntry, g *game, events ...*Event) error {
+	if err := m.save(g, events...); err != nil {
+		return err
+	}
+	m.install(entry, g, events...)
+	return nil
 }
 
-func NewGameManager(s sarama.SyncProducer) *GameManager {
-	return &GameManager{
-		activeGames: make(map[GameID]*game),
-		stream:      s,
+// save records the events in the outbox of the game and saves the game.
+// Events are stored before the game, so events of a save that failed come
+// after the sequence number of the stored game and are dropped when the
+// game is loaded.
+func (m *GameManager) save(g *game, events ...*Event) error {
+	if len(events) > 0 && len(g.Outbox)+len(events) > maxOutboxEvents {
+		return ErrOutboxFull
+	}
+	for _, ev := range events {
+		g.record(ev)
+		if err := m.storeEvent(g.ID, ev); err != nil {
+			return err
+		}
+	}
+	b, err := encodeGame(g)
+	if err != nil {
+		return err
 	}
+	return m.store.Save(g.ID, b)
+}
+
+// install makes the saved game the current state of the entry and sends
+// out its events.
+func (m *GameManager) install(entry *gameEntry, g *game, events ...*Event) {
+	entry.game = g
+	for _, ev := range events {
+		m.watchers.broadcast(ev)
+	}
+	m.relay.notify(g.ID)
+	m.scheduleTimeout(g)
 }
 
 func newID() GameID {
@@ -50,51 +172,128 @@ func newID() GameID {
 }
 
 func (m *GameManager) CreateGame(ctx context.Context, req *CreateRequest) (*CreateReply, error) {
-	if len(req.UserIds) != 2 {
-		return nil, errors.New("number of players must be 2")
+	if len(req.UserIds) < 2 || len(req.UserIds) > MaxPlayers {
+		return nil, ErrInvalidPlayers
+	}
+	seen := make(map[string]bool, len(req.UserIds))
+	for _, userID := range req.UserIds {
+		if userID == "" {
+			return nil, ErrEmptyUserID
+		}
+		if seen[userID] {
+			return nil, ErrDuplicateUserID
+		}
+		seen[userID] = true
+	}
+	players := req.UserIds
+	switch req.TurnOrder {
+	case CreateRequest_LISTED:
+	case CreateRequest_RANDOM:
+		players = make([]string, len(req.UserIds))
+		for i, j := range rand.Perm(len(req.UserIds)) {
+			players[i] = req.UserIds[j]
+		}
+	default:
+		return nil, ErrInvalidTurnOrder
 	}
-
-	var rep CreateReply
 
 	gameID := newID()
-	game := newGame(gameID, req.UserIds[0], req.UserIds[1])
+	game, err := newGame(gameID, req.Variant, players, int(req.Width), int(req.Height), int(req.WinLength))
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	if err := game.setTimeControl(req.TimeControl); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	if err := game.setBots(req.Bots); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	game.SeriesID = string(newID())
+	game.GameNumber = 1
+
+	if err := m.startGame(game); err != nil {
+		return nil, err
+	}
+
+	return &CreateReply{
+		Status:   CreateReply_SUCCESS,
+		GameId:   string(game.ID),
+		SeriesId: game.SeriesID,
+	}, nil
+}
 
-	m.lock.Lock()
-	m.activeGames[game.ID] = game
-	m.lock.Unlock()
+// startGame registers a new game and commits its GAME_CREATED event. A bot
+// that moves first gets its turn once the game is committed.
+func (m *GameManager) startGame(game *game) error {
+	entry := m.games.add(game)
+	defer entry.lock.Unlock()
 
-	rep.Status = CreateReply_SUCCESS
-	rep.GameId = string(game.ID)
+	if err := m.commit(entry, game, createdEvent(game)); err != nil {
+		m.discardGame(game.ID)
+		return err
+	}
+	m.series.add(game)
+	return nil
+}
 
-	ev := Event{
+func createdEvent(game *game) *Event {
+	return &Event{
 		Type:      Event_GAME_CREATED,
 		Timestamp: time.Now().UnixNano(),
-		GameId:    string(gameID),
-		UserId:    req.UserIds[0],
-		UserList:  req.UserIds,
+		GameId:    string(game.ID),
+		UserId:    game.PlayerList[0],
+		UserList:  game.PlayerList,
 
 		NextPlayer: game.activePlayer(),
 		ValidMoves: game.validMoves(),
-	}
-	if err := sendMessage(m.stream, streamTopic, ev.GameId, &ev); err != nil {
-		return nil, err
-	}
 
-	return &rep, nil
+		Width:     int32(game.Grid.width),
+		Height:    int32(game.Grid.height),
+		WinLength: int32(game.WinLength),
+		Variant:   game.Variant,
+
+		TimeControl: game.TimeControl,
+		Clocks:      game.clocks(),
+		Series:      game.series(),
+		Bots:        game.botSeats(),
+	}
 }
 
 func (m *GameManager) PlayTurn(ctx context.Context, req *TurnRequest) (*TurnReply, error) {
+	if req.UserId == "" {
+		return nil, ErrEmptyUserID
+	}
+	if req.Move == nil {
+		return nil, ErrMissingMove
+	}
+
 	var rep TurnReply
 	rep.Status = TurnReply_SUCCESS
 
-	m.lock.Lock()
-	defer m.lock.Unlock()
+	entry, ok := m.games.get(GameID(req.GameId))
+	if !ok {
+		rep.Status = TurnReply_GAME_NOT_FOUND
+		return &rep, nil
+	}
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if entry.game.Expired {
+		rep.Status = TurnReply_GAME_NOT_FOUND
+		return &rep, nil
+	}
+	game := entry.game.clone()
 
-	game := m.activeGames[GameID(req.GameId)]
+	var events []*Event
+	now := time.Now().UnixNano()
+	if loser, ok := game.checkTimeout(now); ok {
+		events = append(events, timeoutEvent(game, loser, now))
+	}
 
 	alreadyFinsihed := game.isFinished()
 
-	err := game.placeMark(req.UserId, req.MoveId, int(req.Move.X), int(req.Move.Y))
+	x, y := game.squareCoordinates(req.Move)
+	err := game.placeMark(req.UserId, req.MoveId, x, y)
 	switch {
 	case err == ErrInvalidMove:
 		rep.Status = TurnReply_INVALID_MOVE
@@ -106,49 +305,93 @@ func (m *GameManager) PlayTurn(ctx context.Context, req *TurnRequest) (*TurnRepl
 		return nil, err
 	}
 
+	move := req.Move
+	if err == nil {
+		move = game.lastSquare()
+	}
+	events = append(events, turnEvent(game, req.UserId, move, rep.Status))
+	if !alreadyFinsihed && game.isFinished() {
+		rep.Status = TurnReply_FINISHED
+	}
 	rep.MoveId = game.lastMoveID()
 
-	ev := Event{
+	if err := m.commit(entry, game, events...); err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+func turnEvent(g *game, userID string, move *TurnRequest_Square, status TurnReply_ResponseStatus) *Event {
+	ev := &Event{
 		Type:      Event_TURN_PLAYED,
 		Timestamp: time.Now().UnixNano(),
-		GameId:    string(req.GameId),
-		UserId:    req.UserId,
-		UserList:  game.PlayerList,
+		GameId:    string(g.ID),
+		UserId:    userID,
+		UserList:  g.PlayerList,
 
-		Move:       req.Move,
-		TurnStatus: rep.Status,
-		MoveId:     rep.MoveId,
+		Move:       move,
+		TurnStatus: status,
+		MoveId:     g.lastMoveID(),
 
-		NextPlayer: game.activePlayer(),
+		NextPlayer: g.activePlayer(),
+		Clocks:     g.clocks(),
+		SubBoards:  g.subBoardResults(),
 	}
-	if !alreadyFinsihed {
-		if game.isFinished() {
-			rep.Status = TurnReply_FINISHED
-		} else {
-			ev.ValidMoves = game.validMoves()
-		}
+	if g.isFinished() {
+		ev.Winner = g.winner()
+	} else {
+		ev.ValidMoves = g.validMoves()
 	}
-	if game.isFinished() {
-		ev.Winner = game.winner()
-	}
-	if err := sendMessage(m.stream, streamTopic, ev.GameId, &ev); err != nil {
-		return nil, err
+	return ev
+}
+
+func (m *GameManager) GetGame(ctx context.Context, req *GetGameRequest) (*GetGameReply, error) {
+	entry, ok := m.games.get(GameID(req.GameId))
+	if !ok {
+		return nil, ErrGameNotFound
 	}
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
 
-	return &rep, nil
+	if entry.game.Expired {
+		return nil, ErrGameNotFound
+	}
+	return &GetGameReply{Game: entry.game.state()}, nil
 }
 
-func sendMessage(p sarama.SyncProducer, topic string, key string, m proto.Message) error {
-	b, err := proto.Marshal(m)
-	if err != nil {
-		glog.Fatalf("Encoding message: %s", err)
+func (m *GameManager) WatchGame(req *WatchRequest, stream GameManager_WatchGameServer) error {
+	gameID := GameID(req.GameId)
+
+	entry, ok := m.games.get(gameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+	entry.lock.Lock()
+	if entry.game.Expired {
+		entry.lock.Unlock()
+		return ErrGameNotFound
 	}
+	snapshot := entry.game.state()
+	w := m.watchers.subscribe(gameID)
+	entry.lock.Unlock()
+
+	defer m.watchers.unsubscribe(gameID, w)
 
-	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Value: sarama.ByteEncoder(b),
-		Key:   sarama.StringEncoder(key),
+	if err := stream.Send(&WatchReply{Snapshot: snapshot}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case ev, ok := <-w.events:
+			if !ok {
+				return w.err
+			}
+			if err := stream.Send(&WatchReply{Event: ev}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
 	}
-	_, _, err = p.SendMessage(msg)
-	return err
 }
@@ -0,0 +1,103 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+const GravityVariant = "gravity"
+
+const (
+	gravityWidth     = 7
+	gravityHeight    = 6
+	gravityWinLength = 4
+)
+
+func init() {
+	RegisterRuleset(GravityVariant, gravityRules{})
+}
+
+// gravityRules are the rules of Connect Four: players choose a column and
+// their mark falls to the lowest empty square of it. Valid moves cover the
+// playable columns on the top row and only the column of a move counts.
+type gravityRules struct{}
+
+func (gravityRules) Setup(g *game, width, height, winLength int) error {
+	if width == 0 {
+		width = gravityWidth
+	}
+	if height == 0 {
+		height = gravityHeight
+	}
+	if winLength == 0 {
+		winLength = minInt(gravityWinLength, minInt(width, height))
+	}
+	width, height, winLength, err := gridDimensions(len(g.PlayerList), width, height, winLength)
+	if err != nil {
+		return err
+	}
+	g.Grid = newGameGrid(width, height)
+	g.WinLength = winLength
+	return nil
+}
+
+func (gravityRules) ValidateMove(g *game, userID string, x, y int) error {
+	if !g.Grid.coordinatesValid(x, 0) || !g.Grid.isEmpty(x, 0) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (gravityRules) ApplyMove(g *game, userID string, x, y int) (int, int) {
+	y = g.Grid.height - 1
+	for !g.Grid.isEmpty(x, y) {
+		y--
+	}
+	g.Grid.set(x, y, g.Players[userID])
+	return x, y
+}
+
+func (gravityRules) Outcome(g *game, userID string, x, y int) *Winner {
+	return standardRules{}.Outcome(g, userID, x, y)
+}
+
+// SetupBoard also checks that every mark rests on the bottom row or on
+// another mark.
+func (gravityRules) SetupBoard(g *game) error {
+	for i, mark := range g.Grid.grid {
+		x, y := i%g.Grid.width, i/g.Grid.width
+		if mark != Mark_EMPTY && y < g.Grid.height-1 && g.Grid.isEmpty(x, y+1) {
+			return ErrInvalidBoard
+		}
+	}
+	return standardRules{}.SetupBoard(g)
+}
+
+func (gravityRules) LegalMoves(g *game) []*MoveRange {
+	moves := make([]*MoveRange, 0)
+	for x := 0; x < g.Grid.width; x++ {
+		if !g.Grid.isEmpty(x, 0) {
+			continue
+		}
+		start := x
+		for x+1 < g.Grid.width && g.Grid.isEmpty(x+1, 0) {
+			x++
+		}
+		r := &MoveRange{FromX: int32(start)}
+		if x > start {
+			r.ToX = int32(x)
+		}
+		moves = append(moves, r)
+	}
+	return moves
+}
@@ -15,48 +15,125 @@
 
 package tictactoe
 
-const GridSize int = 3
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	GridSize    int = 3
+	MaxGridSize int = 32
+
+	maxDefaultWinLength int = 5
+
+	// Games with more than two players default to a board that grows by
+	// two squares per side for every extra player and to four in a row.
+	multiPlayerGridGrowth       int = 2
+	multiPlayerDefaultWinLength int = 4
+)
+
+var (
+	ErrInvalidGridSize  = errors.New("invalid grid size")
+	ErrInvalidWinLength = errors.New("invalid win length")
+)
 
 type gameGrid struct {
-	grid []Mark
+	width  int
+	height int
+	grid   []Mark
 }
 
-func newGameGrid() *gameGrid {
+func newGameGrid(width, height int) *gameGrid {
 	return &gameGrid{
-		grid: make([]Mark, GridSize*GridSize),
+		width:  width,
+		height: height,
+		grid:   make([]Mark, width*height),
 	}
 }
 
-func (g *gameGrid) set(x, y int, m Mark) {
-	g.grid[y*GridSize+x] = m
+// gridDimensions fills in the defaults for a board that was requested with
+// zero values and validates the result. Width defaults to GridSize, height
+// to the width and the win length to the shorter side (at most 5). Boards
+// for more than two players are larger by default.
+func gridDimensions(players, width, height, winLength int) (int, int, int, error) {
+	extra := players - 2
+	if extra < 0 {
+		extra = 0
+	}
+	if width == 0 {
+		width = GridSize + extra*multiPlayerGridGrowth
+	}
+	if height == 0 {
+		height = width
+	}
+	if width < 1 || width > MaxGridSize || height < 1 || height > MaxGridSize {
+		return 0, 0, 0, ErrInvalidGridSize
+	}
+	if winLength == 0 {
+		winLength = minInt(minInt(width, height), maxDefaultWinLength)
+		if extra > 0 {
+			winLength = minInt(winLength, multiPlayerDefaultWinLength)
+		}
+	}
+	if winLength < 2 || winLength > maxInt(width, height) {
+		return 0, 0, 0, ErrInvalidWinLength
+	}
+	return width, height, winLength, nil
 }
 
-func (g *gameGrid) isEmpty(x, y int) bool {
-	return g.get(x, y) == Mark_EMPTY
+type gridJSON struct {
+	Width  int
+	Height int
+	Marks  []Mark
 }
 
-func (g *gameGrid) get(x, y int) Mark {
-	return g.grid[y*GridSize+x]
+func (g *gameGrid) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gridJSON{
+		Width:  g.width,
+		Height: g.height,
+		Marks:  g.grid,
+	})
 }
 
-func (g *gameGrid) checkMarksHorizontal(y int) bool {
-	first := g.get(0, y)
-	return first == g.get(1, y) && first == g.get(2, y)
+func (g *gameGrid) UnmarshalJSON(b []byte) error {
+	var j gridJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	if j.Width < 1 || j.Height < 1 || len(j.Marks) != j.Width*j.Height {
+		return ErrInvalidGridSize
+	}
+	g.width, g.height, g.grid = j.Width, j.Height, j.Marks
+	return nil
 }
 
-func (g *gameGrid) checkDiagonalDown() bool {
-	first := g.get(0, 0)
-	return first == g.get(1, 1) && first == g.get(2, 2) && first != Mark_EMPTY
+func (g *gameGrid) set(x, y int, m Mark) {
+	g.grid[y*g.width+x] = m
 }
 
-func (g *gameGrid) checkDiagonalUp() bool {
-	first := g.get(0, 2)
-	return first == g.get(1, 1) && first == g.get(2, 0) && first != Mark_EMPTY
+func (g *gameGrid) isEmpty(x, y int) bool {
+	return g.get(x, y) == Mark_EMPTY
 }
 
-func (g *gameGrid) checkMarksVertical(x int) bool {
-	first := g.get(x, 0)
-	return first == g.get(x, 1) && first == g.get(x, 2)
+func (g *gameGrid) get(x, y int) Mark {
+	return g.grid[y*g.width+x]
+}
+
+// lineThrough returns the ends and the length of the run of equal marks
+// that goes through (x, y) along (dx, dy). The start is the end that is
+// reached by walking against the direction.
+func (g *gameGrid) lineThrough(x, y, dx, dy int) ([2]int, [2]int, int) {
+	m := g.get(x, y)
+	start, end, length := [2]int{x, y}, [2]int{x, y}, 1
+	for sx, sy := x-dx, y-dy; g.coordinatesValid(sx, sy) && g.get(sx, sy) == m; sx, sy = sx-dx, sy-dy {
+		start = [2]int{sx, sy}
+		length++
+	}
+	for ex, ey := x+dx, y+dy; g.coordinatesValid(ex, ey) && g.get(ex, ey) == m; ex, ey = ex+dx, ey+dy {
+		end = [2]int{ex, ey}
+		length++
+	}
+	return start, end, length
 }
 
 func (g *gameGrid) isFull() bool {
@@ -68,16 +145,40 @@ func (g *gameGrid) isFull() bool {
 	return true
 }
 
+func (g *gameGrid) countEmpty() int {
+	n := 0
+	for _, m := range g.grid {
+		if m == Mark_EMPTY {
+			n++
+		}
+	}
+	return n
+}
+
 func (g *gameGrid) clone() *gameGrid {
-	grid := make([]Mark, GridSize*GridSize)
+	grid := make([]Mark, len(g.grid))
 	copy(grid, g.grid)
-	return &gameGrid{grid: grid}
+	return &gameGrid{width: g.width, height: g.height, grid: grid}
 }
 
 func (g *gameGrid) coordinatesValid(x, y int) bool {
-	return validateIndex(x) && validateIndex(y)
+	return validateIndex(x, g.width) && validateIndex(y, g.height)
+}
+
+func validateIndex(x, size int) bool {
+	return x >= 0 && x < size
 }
 
-func validateIndex(x int) bool {
-	return x >= 0 && x < GridSize
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
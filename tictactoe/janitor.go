@@ -0,0 +1,109 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
+)
+
+const janitorInterval = time.Minute
+
+// RetentionPolicy defines how long games are kept. A zero duration keeps
+// the games forever.
+type RetentionPolicy struct {
+	// Finished is how long a game is kept after it has finished.
+	Finished time.Duration
+	// Idle is how long an unfinished game is kept without a move.
+	Idle time.Duration
+}
+
+func (p RetentionPolicy) expired(g *game, now int64) bool {
+	if g.isFinished() {
+		return p.Finished > 0 && now-g.FinishedTimestamp > int64(p.Finished)
+	}
+	return p.Idle > 0 && now-g.lastActivity() > int64(p.Idle)
+}
+
+// StartJanitor periodically expires the games that are past the retention
+// policy. An expired game emits a GAME_EXPIRED event and is removed once
+// all of its events have been published.
+func (m *GameManager) StartJanitor(policy RetentionPolicy) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		t := time.NewTicker(janitorInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				m.reap(policy)
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (m *GameManager) reap(policy RetentionPolicy) {
+	now := time.Now().UnixNano()
+	for _, entry := range m.games.entries() {
+		entry.lock.Lock()
+		switch {
+		case entry.game.Expired && len(entry.game.Outbox) == 0:
+			m.removeGame(entry.game)
+		case !entry.game.Expired && policy.expired(entry.game, now):
+			m.expireGame(entry, now)
+		}
+		entry.lock.Unlock()
+	}
+}
+
+func (m *GameManager) expireGame(entry *gameEntry, now int64) {
+	g := entry.game.clone()
+	g.Expired = true
+	ev := &Event{
+		Type:      Event_GAME_EXPIRED,
+		Timestamp: now,
+		GameId:    string(g.ID),
+		UserList:  g.PlayerList,
+		Winner:    g.winner(),
+	}
+	if err := m.commit(entry, g, ev); err != nil {
+		glog.Errorf("Expiring game %s: %s", g.ID, err)
+	}
+}
+
+func (m *GameManager) removeGame(g *game) {
+	if err := m.store.Delete(g.ID); err != nil {
+		glog.Errorf("Deleting game %s: %s", g.ID, err)
+		return
+	}
+	m.games.remove(g.ID)
+	m.watchers.closeGame(g.ID)
+	m.timeouts.cancel(g.ID)
+	m.series.remove(g)
+}
+
+// discardGame removes a new game whose events were never sent. Saving it
+// may have failed half way, so whatever was stored is deleted.
+func (m *GameManager) discardGame(id GameID) {
+	m.games.remove(id)
+	if err := m.store.Delete(id); err != nil {
+		glog.Errorf("Deleting discarded game %s: %s", id, err)
+	}
+}
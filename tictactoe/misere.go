@@ -0,0 +1,57 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+const MisereVariant = "misere"
+
+func init() {
+	RegisterRuleset(MisereVariant, misereRules{})
+}
+
+// misereRules are the rules of a two-player m,n,k-game in which the player
+// that completes a line of k marks loses.
+type misereRules struct {
+	standardRules
+}
+
+func (r misereRules) Setup(g *game, width, height, winLength int) error {
+	if len(g.PlayerList) != 2 {
+		return ErrTwoPlayerVariant
+	}
+	return r.standardRules.Setup(g, width, height, winLength)
+}
+
+func (misereRules) Outcome(g *game, userID string, x, y int) *Winner {
+	if w := lineWinner(g.Grid, g.WinLength, userID, x, y); w != nil {
+		return losingLine(g, w)
+	}
+	if g.Grid.isFull() {
+		return &Winner{Draw: true}
+	}
+	return nil
+}
+
+// Solvable is false since the solver looks for lines that win.
+func (misereRules) Solvable() bool { return false }
+
+// losingLine turns the lines completed by a player into a loss of that
+// player.
+func losingLine(g *game, w *Winner) *Winner {
+	w.LoserUserId = w.UserId
+	w.UserId = g.opponent(w.UserId)
+	w.Reason = Winner_LOSING_LINE
+	return w
+}
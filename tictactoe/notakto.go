@@ -0,0 +1,135 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+const NotaktoVariant = "notakto"
+
+const notaktoBoardSize = 3
+
+func init() {
+	RegisterRuleset(NotaktoVariant, notaktoRules{})
+}
+
+// notaktoRules are the rules of Notakto: both players place X on one or
+// more 3x3 boards. A board with three in a row is dead and the player that
+// kills the last board loses. The boards are placed next to each other and
+// the width of the grid sets their number.
+type notaktoRules struct{}
+
+func (notaktoRules) Setup(g *game, width, height, winLength int) error {
+	if len(g.PlayerList) != 2 {
+		return ErrTwoPlayerVariant
+	}
+	if width == 0 {
+		width = notaktoBoardSize
+	}
+	if width%notaktoBoardSize != 0 || width > MaxGridSize || height != 0 && height != notaktoBoardSize {
+		return ErrInvalidGridSize
+	}
+	if winLength != 0 && winLength != notaktoBoardSize {
+		return ErrInvalidWinLength
+	}
+	for userID := range g.Players {
+		g.Players[userID] = Mark_X
+	}
+	g.Grid = newGameGrid(width, notaktoBoardSize)
+	g.WinLength = notaktoBoardSize
+	return nil
+}
+
+func (notaktoRules) ValidateMove(g *game, userID string, x, y int) error {
+	if !g.Grid.coordinatesValid(x, y) || !g.Grid.isEmpty(x, y) || g.notaktoDead(x/notaktoBoardSize) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (notaktoRules) ApplyMove(g *game, userID string, x, y int) (int, int) {
+	g.Grid.set(x, y, Mark_X)
+	return x, y
+}
+
+func (notaktoRules) Outcome(g *game, userID string, x, y int) *Winner {
+	for b := 0; b < g.Grid.width/notaktoBoardSize; b++ {
+		if b != x/notaktoBoardSize && !g.notaktoDead(b) {
+			return nil
+		}
+	}
+	bx := x / notaktoBoardSize * notaktoBoardSize
+	w := lineWinner(g.notaktoBoard(x/notaktoBoardSize), notaktoBoardSize, userID, x-bx, y)
+	if w == nil {
+		return nil
+	}
+	for _, loc := range w.Locations {
+		loc.Start.X += int32(bx)
+		loc.End.X += int32(bx)
+	}
+	return losingLine(g, w)
+}
+
+func (notaktoRules) LegalMoves(g *game) []*MoveRange {
+	grid := g.Grid.clone()
+	for b := 0; b < grid.width/notaktoBoardSize; b++ {
+		if !g.notaktoDead(b) {
+			continue
+		}
+		for y := 0; y < notaktoBoardSize; y++ {
+			for x := 0; x < notaktoBoardSize; x++ {
+				grid.set(b*notaktoBoardSize+x, y, Mark_X)
+			}
+		}
+	}
+	return emptySquares(grid)
+}
+
+// SetupBoard gives the turn to the second player after an odd number of
+// marks, since both players place X.
+func (notaktoRules) SetupBoard(g *game) error {
+	g.CurrentPlayer = g.TurnNumber % 2
+	return nil
+}
+
+func (notaktoRules) NearbyMoves() bool { return true }
+func (notaktoRules) Solvable() bool    { return false }
+
+// notaktoBoard returns a copy of board b.
+func (g *game) notaktoBoard(b int) *gameGrid {
+	board := newGameGrid(notaktoBoardSize, notaktoBoardSize)
+	for y := 0; y < notaktoBoardSize; y++ {
+		for x := 0; x < notaktoBoardSize; x++ {
+			board.set(x, y, g.Grid.get(b*notaktoBoardSize+x, y))
+		}
+	}
+	return board
+}
+
+// notaktoDead reports whether board b has three in a row.
+func (g *game) notaktoDead(b int) bool {
+	board := g.notaktoBoard(b)
+	for y := 0; y < notaktoBoardSize; y++ {
+		for x := 0; x < notaktoBoardSize; x++ {
+			if board.isEmpty(x, y) {
+				continue
+			}
+			for _, d := range lineDirections {
+				if _, _, n := board.lineThrough(x, y, d.dx, d.dy); n >= notaktoBoardSize {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/protobuf/proto"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc/codes"
+)
+
+const (
+	relayWorkers    = 8
+	relayMinBackoff = 100 * time.Millisecond
+	relayMaxBackoff = 10 * time.Second
+
+	// maxOutboxEvents is the number of unpublished events a game can
+	// have. Operations that would record more fail until the events are
+	// published.
+	maxOutboxEvents = 1000
+)
+
+var ErrOutboxFull = grpc.Errorf(codes.Unavailable, "too many unpublished events")
+
+// record assigns the next sequence number of the game to the event and puts
+// it into the outbox of the game. It must be called in the same critical
+// section as the state change the event describes.
+func (g *game) record(ev *Event) {
+	g.Sequence++
+	ev.Sequence = g.Sequence
+	g.Outbox = append(g.Outbox, ev)
+}
+
+func (m *GameManager) storeEvent(id GameID, ev *Event) error {
+	b, err := proto.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return m.store.AppendEvent(id, ev.Sequence, b)
+}
+
+// loadOutbox reads the outbox of a game from the store. The outbox of a
+// game saved by an older version is moved to the store first.
+func (m *GameManager) loadOutbox(g *game) error {
+	if len(g.Outbox) > 0 {
+		for _, ev := range g.Outbox {
+			if err := m.storeEvent(g.ID, ev); err != nil {
+				return err
+			}
+		}
+		b, err := encodeGame(g)
+		if err != nil {
+			return err
+		}
+		if err := m.store.Save(g.ID, b); err != nil {
+			return err
+		}
+	}
+	data, err := m.store.LoadEvents(g.ID)
+	if err != nil {
+		return err
+	}
+	g.Outbox = nil
+	for _, b := range data {
+		ev := new(Event)
+		if err := proto.Unmarshal(b, ev); err != nil {
+			return err
+		}
+		if ev.Sequence > g.Sequence {
+			// Recorded by a commit that failed to save the game.
+			if err := m.store.DeleteEvent(g.ID, ev.Sequence); err != nil {
+				return err
+			}
+			continue
+		}
+		g.Outbox = append(g.Outbox, ev)
+	}
+	return nil
+}
+
+// relay keeps track of the games that have events waiting in their outbox.
+// A game is handed to at most one worker at a time so that the events of a
+// game are published in order.
+type relay struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	queue   []GameID
+	pending map[GameID]bool
+	closed  bool
+}
+
+func newRelay() *relay {
+	r := &relay{
+		pending: make(map[GameID]bool),
+	}
+	r.cond = sync.NewCond(&r.lock)
+	return r
+}
+
+// notify schedules the outbox of the game for publishing.
+func (r *relay) notify(id GameID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pending[id] {
+		return
+	}
+	r.pending[id] = true
+	r.queue = append(r.queue, id)
+	r.cond.Signal()
+}
+
+func (r *relay) next() (GameID, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for len(r.queue) == 0 && !r.closed {
+		r.cond.Wait()
+	}
+	if r.closed {
+		return "", false
+	}
+	id := r.queue[0]
+	r.queue = r.queue[1:]
+	return id, true
+}
+
+// done marks the outbox of the game as empty. It has to be called with the
+// lock of the game held, otherwise an event recorded in between would not
+// be scheduled.
+func (r *relay) done(id GameID) {
+	r.lock.Lock()
+	delete(r.pending, id)
+	r.lock.Unlock()
+}
+
+func (r *relay) close() {
+	r.lock.Lock()
+	r.closed = true
+	r.cond.Broadcast()
+	r.lock.Unlock()
+}
+
+func (m *GameManager) startRelay() {
+	for i := 0; i < relayWorkers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				id, ok := m.relay.next()
+				if !ok {
+					return
+				}
+				m.drainOutbox(id)
+			}
+		}()
+	}
+}
+
+// drainOutbox publishes the events in the outbox of the game until it is
+// empty. An event is only removed from the outbox once it was published, so
+// every event is delivered at least once.
+func (m *GameManager) drainOutbox(id GameID) {
+	for {
+		entry, ok := m.games.get(id)
+		if !ok {
+			m.relay.done(id)
+			return
+		}
+		entry.lock.Lock()
+		if len(entry.game.Outbox) == 0 {
+			m.relay.done(id)
+			entry.lock.Unlock()
+			return
+		}
+		ev := entry.game.Outbox[0]
+		entry.lock.Unlock()
+
+		if !m.publish(ev) {
+			return
+		}
+
+		entry.lock.Lock()
+		if len(entry.game.Outbox) > 0 && entry.game.Outbox[0] == ev {
+			entry.game.Outbox = entry.game.Outbox[1:]
+			m.deleteEvent(id, ev)
+		}
+		entry.lock.Unlock()
+	}
+}
+
+// deleteEvent removes a published event from the stored outbox, so it is
+// not published again after a restart. If that fails the event may be
+// published twice, which at least once delivery allows.
+func (m *GameManager) deleteEvent(id GameID, ev *Event) {
+	if err := m.store.DeleteEvent(id, ev.Sequence); err != nil {
+		glog.Warningf("Deleting event %d of game %s: %s", ev.Sequence, id, err)
+	}
+}
+
+// publish retries publishing the event with an exponential backoff until it
+// succeeds or the relay is stopped.
+func (m *GameManager) publish(ev *Event) bool {
+	backoff := relayMinBackoff
+	for {
+		err := m.events.Publish(ev)
+		if err == nil {
+			return true
+		}
+		glog.Warningf("Publishing event %d of game %s: %s", ev.Sequence, ev.GameId, err)
+		select {
+		case <-time.After(backoff):
+		case <-m.stop:
+			return false
+		}
+		backoff *= 2
+		if backoff > relayMaxBackoff {
+			backoff = relayMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"sync"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/Shopify/sarama"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/protobuf/proto"
+)
+
+const StreamTopic = "tictactoe-game-events"
+
+// EventPublisher delivers game events to their consumers.
+type EventPublisher interface {
+	Publish(ev *Event) error
+}
+
+// KafkaPublisher publishes events to a Kafka topic keyed by game id, so all
+// events of a game end up in the same partition.
+type KafkaPublisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewKafkaPublisher(p sarama.SyncProducer, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		producer: p,
+		topic:    topic,
+	}
+}
+
+func (p *KafkaPublisher) Publish(ev *Event) error {
+	b, err := proto.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Value: sarama.ByteEncoder(b),
+		Key:   sarama.StringEncoder(ev.GameId),
+	}
+	_, _, err = p.producer.SendMessage(msg)
+	return err
+}
+
+// MemoryPublisher keeps all published events in memory. It is meant for
+// tests and for running the service without a broker.
+type MemoryPublisher struct {
+	lock   sync.Mutex
+	events []*Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ev *Event) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.events = append(p.events, proto.Clone(ev).(*Event))
+	return nil
+}
+
+// Events returns the events published so far.
+func (p *MemoryPublisher) Events() []*Event {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	events := make([]*Event, len(p.events))
+	copy(events, p.events)
+	return events
+}
+
+type fanOutPublisher []EventPublisher
+
+// NewFanOutPublisher returns a publisher that publishes every event to all
+// of the given publishers. Every publisher gets the event even if an earlier
+// one fails; the first error is returned.
+func NewFanOutPublisher(publishers ...EventPublisher) EventPublisher {
+	return fanOutPublisher(publishers)
+}
+
+func (f fanOutPublisher) Publish(ev *Event) error {
+	var firstErr error
+	for _, p := range f {
+		if err := p.Publish(ev); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const registryShards = 32
+
+// gameEntry guards a game. The lock of the entry is held while an operation
+// changes the game, saves it to the store and appends its events to the
+// outbox, so that the events of a game are recorded in order. The events
+// are published by the relay outside of the lock.
+type gameEntry struct {
+	lock sync.Mutex
+	game *game
+}
+
+type registryShard struct {
+	lock  sync.RWMutex
+	games map[GameID]*gameEntry
+}
+
+// gameRegistry holds the active games. The games are spread over several
+// shards so that looking up a game does not contend with operations on
+// unrelated games.
+type gameRegistry struct {
+	shards [registryShards]registryShard
+}
+
+func newGameRegistry() *gameRegistry {
+	r := &gameRegistry{}
+	for i := range r.shards {
+		r.shards[i].games = make(map[GameID]*gameEntry)
+	}
+	return r
+}
+
+func (r *gameRegistry) shard(id GameID) *registryShard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &r.shards[h.Sum32()%registryShards]
+}
+
+// add registers the game and returns its entry locked, so the caller can
+// finish setting up the game before anyone else gets to it.
+func (r *gameRegistry) add(g *game) *gameEntry {
+	e := &gameEntry{game: g}
+	e.lock.Lock()
+
+	s := r.shard(g.ID)
+	s.lock.Lock()
+	s.games[g.ID] = e
+	s.lock.Unlock()
+	return e
+}
+
+func (r *gameRegistry) get(id GameID) (*gameEntry, bool) {
+	s := r.shard(id)
+	s.lock.RLock()
+	e, ok := s.games[id]
+	s.lock.RUnlock()
+	return e, ok
+}
+
+func (r *gameRegistry) remove(id GameID) {
+	s := r.shard(id)
+	s.lock.Lock()
+	delete(s.games, id)
+	s.lock.Unlock()
+}
+
+// entries returns the entries of all registered games.
+func (r *gameRegistry) entries() []*gameEntry {
+	var entries []*gameEntry
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.lock.RLock()
+		for _, e := range s.games {
+			entries = append(entries, e)
+		}
+		s.lock.RUnlock()
+	}
+	return entries
+}
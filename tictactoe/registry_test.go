@@ -0,0 +1,124 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+// slowPublisher takes a while to publish every event, like a remote broker.
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(ev *Event) error {
+	return nil
+}
+
+// slowStore takes its time to save a game. Games are saved while their
+// lock is held, so a slow save stalls the game that is saved.
+type slowStore struct {
+	GameStore
+	delay time.Duration
+}
+
+func (s slowStore) Save(id GameID, data []byte) error {
+	time.Sleep(s.delay)
+	return s.GameStore.Save(id, data)
+}
+
+// benchmarkPlayTurn plays turns in the given number of games at once and
+// reports the turns played per second. With singleLock every turn holds
+// one lock shared by all games, like the manager did before games were
+// locked individually.
+func benchmarkPlayTurn(b *testing.B, games int, singleLock bool) {
+	m := NewGameManager(nopPublisher{}, slowStore{NewMemoryStore(), time.Millisecond})
+	defer m.Close()
+	ctx := context.Background()
+	players := []string{"a", "b"}
+	var lock sync.Mutex
+	playTurn := func(req *TurnRequest) (*TurnReply, error) {
+		if singleLock {
+			lock.Lock()
+			defer lock.Unlock()
+		}
+		return m.PlayTurn(ctx, req)
+	}
+
+	b.ResetTimer()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		turns := b.N / games
+		if i < b.N%games {
+			turns++
+		}
+		wg.Add(1)
+		go func(turns int) {
+			defer wg.Done()
+			var gameID string
+			var moveID int64
+			var sq int32
+			for t := 0; t < turns; t++ {
+				if gameID == "" {
+					c, err := m.CreateGame(ctx, &CreateRequest{UserIds: players, Width: benchmarkGridSize})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					gameID, moveID, sq = c.GameId, 0, 0
+				}
+				rep, err := playTurn(&TurnRequest{
+					GameId: gameID,
+					UserId: players[sq%2],
+					MoveId: moveID,
+					Move:   &TurnRequest_Square{X: sq % benchmarkGridSize, Y: sq / benchmarkGridSize},
+				})
+				switch {
+				case err != nil:
+					b.Error(err)
+					return
+				case rep.Status == TurnReply_FINISHED:
+					gameID = ""
+				case rep.Status != TurnReply_SUCCESS:
+					b.Errorf("turn %d of game %s: %s", sq, gameID, rep.Status)
+					return
+				}
+				moveID = rep.MoveId
+				sq++
+			}
+		}(turns)
+	}
+	wg.Wait()
+	b.StopTimer()
+	b.Logf("%d games: %.0f turns/s", games, float64(b.N)/time.Since(start).Seconds())
+}
+
+const benchmarkGridSize = 15
+
+func BenchmarkPlayTurn1(b *testing.B)   { benchmarkPlayTurn(b, 1, false) }
+func BenchmarkPlayTurn4(b *testing.B)   { benchmarkPlayTurn(b, 4, false) }
+func BenchmarkPlayTurn16(b *testing.B)  { benchmarkPlayTurn(b, 16, false) }
+func BenchmarkPlayTurn64(b *testing.B)  { benchmarkPlayTurn(b, 64, false) }
+func BenchmarkPlayTurn256(b *testing.B) { benchmarkPlayTurn(b, 256, false) }
+
+func BenchmarkPlayTurnSingleLock1(b *testing.B)   { benchmarkPlayTurn(b, 1, true) }
+func BenchmarkPlayTurnSingleLock4(b *testing.B)   { benchmarkPlayTurn(b, 4, true) }
+func BenchmarkPlayTurnSingleLock16(b *testing.B)  { benchmarkPlayTurn(b, 16, true) }
+func BenchmarkPlayTurnSingleLock64(b *testing.B)  { benchmarkPlayTurn(b, 64, true) }
+func BenchmarkPlayTurnSingleLock256(b *testing.B) { benchmarkPlayTurn(b, 256, true) }
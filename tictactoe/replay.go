@@ -0,0 +1,222 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/Shopify/sarama"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/glog"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/github.com/golang/protobuf/proto"
+)
+
+var ErrConsumerClosed = errors.New("partition consumer closed before the end of the replay")
+
+// ReplayKafka rebuilds the games from the events in the topic. Every
+// partition is read from the given offset (a literal offset or
+// sarama.OffsetOldest) up to the last message that was in the partition
+// when the replay started. Events that are already part of a game, for
+// example because the game was loaded from the store, are skipped. The
+// timeouts have to be held with HoldTimeouts before the games are loaded
+// and released after the replay, otherwise a game could time out or a bot
+// could move in a state that the replay brings up to date.
+func (m *GameManager) ReplayKafka(client sarama.Client, topic string, offset int64) error {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return err
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	for _, partition := range partitions {
+		if err := m.replayPartition(client, consumer, topic, partition, offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *GameManager) replayPartition(client sarama.Client, consumer sarama.Consumer, topic string, partition int32, offset int64) error {
+	end, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return err
+	}
+	if offset >= end {
+		return nil
+	}
+	if offset == sarama.OffsetOldest {
+		start, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return err
+		}
+		if start >= end {
+			return nil
+		}
+	}
+
+	pc, err := consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	glog.Infof("Replaying partition %d of %s up to offset %d", partition, topic, end)
+	for {
+		select {
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return ErrConsumerClosed
+			}
+			var ev Event
+			if err := proto.Unmarshal(msg.Value, &ev); err != nil {
+				glog.Errorf("Skipping undecodable event at offset %d of partition %d: %s", msg.Offset, partition, err)
+			} else if err := m.replay(&ev); err != nil {
+				glog.Errorf("Skipping event %d of game %s: %s", ev.Sequence, ev.GameId, err)
+			}
+			if msg.Offset >= end-1 {
+				return nil
+			}
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return ErrConsumerClosed
+			}
+			return err
+		}
+	}
+}
+
+// replay applies an event that was published earlier to the game it
+// belongs to. Replayed events are not published again.
+func (m *GameManager) replay(ev *Event) error {
+	id := GameID(ev.GameId)
+
+	if ev.Type == Event_GAME_CREATED {
+		if _, ok := m.games.get(id); ok {
+			return nil
+		}
+		if len(ev.UserList) < 2 || len(ev.UserList) > MaxPlayers {
+			return ErrInvalidPlayers
+		}
+		g, err := newGame(id, ev.Variant, ev.UserList, int(ev.Width), int(ev.Height), int(ev.WinLength))
+		if err != nil {
+			return err
+		}
+		if err := g.setTimeControl(ev.TimeControl); err != nil {
+			return err
+		}
+		if err := g.setBots(ev.Bots); err != nil {
+			return err
+		}
+		if ev.Series != nil {
+			g.SeriesID = ev.Series.SeriesId
+			g.GameNumber = int(ev.Series.GameNumber)
+			g.SeriesScore = scoreOf(ev.Series)
+		}
+		g.Sequence = ev.Sequence
+		g.CreatedTimestamp = ev.Timestamp
+		entry := m.games.add(g)
+		defer entry.lock.Unlock()
+		if err := m.commit(entry, g); err != nil {
+			m.games.remove(id)
+			return err
+		}
+		m.series.add(g)
+		return nil
+	}
+
+	entry, ok := m.games.get(id)
+	if !ok {
+		return ErrGameNotFound
+	}
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if ev.Sequence != 0 && ev.Sequence <= entry.game.Sequence {
+		return nil
+	}
+	g := entry.game.clone()
+
+	switch ev.Type {
+	case Event_TURN_PLAYED:
+		if ev.TurnStatus != TurnReply_SUCCESS {
+			break
+		}
+		if ev.Move == nil {
+			return ErrMissingMove
+		}
+		x, y := g.squareCoordinates(ev.Move)
+		if err := g.placeMark(ev.UserId, g.lastMoveID(), x, y); err != nil {
+			return err
+		}
+		// Restore the turn timestamp from the move id so the move ids
+		// that clients already know stay valid.
+		g.TurnTimestamp = (ev.MoveId >> 16) * 1000000000
+		if g.isFinished() {
+			g.FinishedTimestamp = ev.Timestamp
+		}
+		restoreClocks(g, ev.Clocks)
+	case Event_TAKEBACK_REQUESTED:
+		g.Takeback = &takebackRequest{UserID: ev.UserId, Plies: int(ev.Plies)}
+	case Event_TAKEBACK_DECLINED:
+		g.Takeback = nil
+	case Event_MOVES_TAKEN_BACK:
+		g.Takeback = nil
+		if err := g.takeBack(int(ev.Plies)); err != nil {
+			return err
+		}
+		g.TurnTimestamp = (ev.MoveId >> 16) * 1000000000
+	case Event_DRAW_OFFERED:
+		g.DrawOffer = ev.UserId
+	case Event_DRAW_DECLINED:
+		g.DrawOffer = ""
+	case Event_GAME_TIMED_OUT, Event_RESIGNED, Event_ABORTED, Event_DRAW_ACCEPTED:
+		if ev.Winner == nil {
+			// The player was eliminated and the others played on.
+			if !g.isEliminated(ev.UserId) {
+				g.eliminate(ev.UserId, ev.Timestamp)
+				g.TurnTimestamp = (ev.MoveId >> 16) * 1000000000
+			}
+		} else if !g.isFinished() {
+			g.finish(ev.Winner)
+			g.FinishedTimestamp = ev.Timestamp
+		}
+		restoreClocks(g, ev.Clocks)
+	case Event_REMATCH_CREATED:
+		g.RematchID = GameID(ev.RematchGameId)
+	case Event_GAME_EXPIRED:
+		g.Expired = true
+	default:
+		glog.Warningf("Not replaying event of unknown type %s", ev.Type)
+	}
+
+	if ev.Sequence != 0 {
+		g.Sequence = ev.Sequence
+	}
+	return m.commit(entry, g)
+}
+
+func restoreClocks(g *game, clocks []*Clock) {
+	if g.Clocks == nil {
+		return
+	}
+	for _, c := range clocks {
+		g.Clocks[c.UserId] = c.RemainingMs * int64(time.Millisecond)
+	}
+}
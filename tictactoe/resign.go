@@ -0,0 +1,196 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+var (
+	ErrNotPlayer    = errors.New("not a player of the game")
+	ErrGameFinished = errors.New("game already finished")
+	// Draws and takebacks need the agreement of the opponent, so they are
+	// only possible between two players.
+	ErrTooManyPlayers = errors.New("too many players")
+)
+
+// opponent returns the player in play that moves after the given player.
+func (g *game) opponent(userID string) string {
+	for i, id := range g.PlayerList {
+		if id != userID {
+			continue
+		}
+		for j := 1; j < len(g.PlayerList); j++ {
+			next := g.PlayerList[(i+j)%len(g.PlayerList)]
+			if !g.isEliminated(next) {
+				return next
+			}
+		}
+	}
+	return ""
+}
+
+// checkPlayer checks that the user is still in play in a running game.
+func (g *game) checkPlayer(userID string) error {
+	if g.isFinished() {
+		return ErrGameFinished
+	}
+	if _, ok := g.Players[userID]; !ok || g.isEliminated(userID) {
+		return ErrNotPlayer
+	}
+	return nil
+}
+
+// leave takes a player out of the game. With more than two players in play
+// the player is eliminated and the others play on, otherwise the opponent
+// wins.
+func (g *game) leave(userID string, reason Winner_Reason, now int64) {
+	if len(g.playersInPlay()) > 2 {
+		g.eliminate(userID, now)
+		return
+	}
+	g.finish(&Winner{
+		UserId: g.opponent(userID),
+		Reason: reason,
+	})
+}
+
+// eliminate takes a player out of play. If it was their turn the turn of
+// the next player starts now.
+func (g *game) eliminate(userID string, now int64) {
+	active := g.activePlayer() == userID
+	g.Eliminated = append(g.Eliminated, userID)
+	if g.DrawOffer == userID {
+		g.DrawOffer = ""
+	}
+	if active {
+		g.updateActivePlayer()
+		g.TurnTimestamp = now
+	}
+}
+
+func (g *game) resign(userID string, now int64) error {
+	if err := g.checkPlayer(userID); err != nil {
+		return err
+	}
+	g.leave(userID, Winner_RESIGNATION, now)
+	return nil
+}
+
+// abort ends the game without a result if nobody has moved yet. Otherwise
+// it counts as resigning.
+func (g *game) abort(userID string, now int64) error {
+	if err := g.checkPlayer(userID); err != nil {
+		return err
+	}
+	if g.TurnNumber == 0 {
+		g.finish(&Winner{Reason: Winner_ABORT})
+		return nil
+	}
+	g.leave(userID, Winner_ABORT, now)
+	return nil
+}
+
+// lockGame returns the locked entry of a game that has not expired. Time
+// outs that are due are committed first so that the caller sees the game
+// as it is now.
+func (m *GameManager) lockGame(id GameID) (*gameEntry, error) {
+	entry, ok := m.games.get(id)
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	entry.lock.Lock()
+	if entry.game.Expired {
+		entry.lock.Unlock()
+		return nil, ErrGameNotFound
+	}
+	g := entry.game.clone()
+	now := time.Now().UnixNano()
+	if loser, ok := g.checkTimeout(now); ok {
+		if err := m.commit(entry, g, timeoutEvent(g, loser, now)); err != nil {
+			entry.lock.Unlock()
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// playerAction runs action on a clone of the game and commits the result
+// with an event of the given type. The action can add details to the
+// event.
+func (m *GameManager) playerAction(gameID, userID string, t Event_Type, action func(g *game, ev *Event) error) error {
+	entry, err := m.lockGame(GameID(gameID))
+	if err != nil {
+		return err
+	}
+	defer entry.lock.Unlock()
+
+	g := entry.game.clone()
+	ev := &Event{
+		Type:      t,
+		Timestamp: time.Now().UnixNano(),
+		GameId:    gameID,
+		UserId:    userID,
+		UserList:  g.PlayerList,
+	}
+	if err := action(g, ev); err != nil {
+		return err
+	}
+	ev.MoveId = g.lastMoveID()
+	ev.NextPlayer = g.activePlayer()
+	ev.Winner = g.winner()
+	ev.Clocks = g.clocks()
+	return m.commit(entry, g, ev)
+}
+
+func (m *GameManager) Resign(ctx context.Context, req *ResignRequest) (*ResignReply, error) {
+	var rep ResignReply
+	err := m.playerAction(req.GameId, req.UserId, Event_RESIGNED, func(g *game, ev *Event) error {
+		return g.resign(req.UserId, ev.Timestamp)
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = ResignReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = ResignReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = ResignReply_FINISHED
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (m *GameManager) Abort(ctx context.Context, req *AbortRequest) (*AbortReply, error) {
+	var rep AbortReply
+	err := m.playerAction(req.GameId, req.UserId, Event_ABORTED, func(g *game, ev *Event) error {
+		return g.abort(req.UserId, ev.Timestamp)
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = AbortReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = AbortReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = AbortReply_FINISHED
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
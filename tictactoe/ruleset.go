@@ -0,0 +1,224 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"fmt"
+)
+
+const DefaultVariant = "standard"
+
+var (
+	ErrUnknownVariant   = errors.New("unknown game variant")
+	ErrTwoPlayerVariant = errors.New("game variant is for two players only")
+)
+
+// Ruleset implements the rules of a game variant. A game delegates every
+// rule decision to the ruleset registered under its variant name.
+type Ruleset interface {
+	// Setup validates the requested board dimensions, fills in the variant
+	// defaults and creates the grid of a new game.
+	Setup(g *game, width, height, winLength int) error
+
+	// ValidateMove checks whether the active player may play at (x, y).
+	ValidateMove(g *game, userID string, x, y int) error
+
+	// ApplyMove places the mark of the player and returns the square that
+	// was actually marked.
+	ApplyMove(g *game, userID string, x, y int) (int, int)
+
+	// Outcome returns the result of the game after a move was applied at
+	// (x, y) or nil if the game is not finished yet.
+	Outcome(g *game, userID string, x, y int) *Winner
+
+	// LegalMoves lists the moves available to the active player.
+	LegalMoves(g *game) []*MoveRange
+}
+
+// BoardRuleset is implemented by rulesets whose positions follow from the
+// marks on the board, so that a supplied board can be analyzed. Variants
+// whose position depends on the order of the moves, like ultimate, do not
+// implement it.
+type BoardRuleset interface {
+	Ruleset
+
+	// SetupBoard sets the player to move in a two-player game whose grid
+	// holds the marks of a supplied board. It fails with ErrInvalidBoard
+	// if the board cannot occur in the variant.
+	SetupBoard(g *game) error
+}
+
+// SearchRuleset is implemented by rulesets that let bots and the analysis
+// take shortcuts. Games of other rulesets are searched without any.
+type SearchRuleset interface {
+	Ruleset
+
+	// NearbyMoves reports whether moves mark the square that was chosen,
+	// so that squares far away from the marks can be left out of a search.
+	NearbyMoves() bool
+
+	// Solvable reports whether two-player games are m,n,k-games that the
+	// solver can solve.
+	Solvable() bool
+}
+
+func nearbyMoves(g *game) bool {
+	r, ok := g.rules().(SearchRuleset)
+	return ok && r.NearbyMoves()
+}
+
+func solvable(g *game) bool {
+	r, ok := g.rules().(SearchRuleset)
+	return ok && r.Solvable()
+}
+
+var rulesets = make(map[string]Ruleset)
+
+// RegisterRuleset makes a game variant available under the given name. It
+// panics if a ruleset is registered twice under the same name.
+func RegisterRuleset(name string, r Ruleset) {
+	if _, dup := rulesets[name]; dup {
+		panic(fmt.Sprintf("tictactoe: ruleset %q registered twice", name))
+	}
+	rulesets[name] = r
+}
+
+func lookupRuleset(name string) (Ruleset, error) {
+	if name == "" {
+		name = DefaultVariant
+	}
+	r, ok := rulesets[name]
+	if !ok {
+		return nil, ErrUnknownVariant
+	}
+	return r, nil
+}
+
+func init() {
+	RegisterRuleset(DefaultVariant, standardRules{})
+}
+
+// standardRules are the rules of an m,n,k-game: players take turns marking
+// empty squares and the first one to get k marks in a row wins.
+type standardRules struct{}
+
+func (standardRules) Setup(g *game, width, height, winLength int) error {
+	width, height, winLength, err := gridDimensions(len(g.PlayerList), width, height, winLength)
+	if err != nil {
+		return err
+	}
+	g.Grid = newGameGrid(width, height)
+	g.WinLength = winLength
+	return nil
+}
+
+func (standardRules) ValidateMove(g *game, userID string, x, y int) error {
+	if !g.Grid.coordinatesValid(x, y) || !g.Grid.isEmpty(x, y) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (standardRules) ApplyMove(g *game, userID string, x, y int) (int, int) {
+	g.Grid.set(x, y, g.Players[userID])
+	return x, y
+}
+
+func (standardRules) Outcome(g *game, userID string, x, y int) *Winner {
+	if w := lineWinner(g.Grid, g.WinLength, userID, x, y); w != nil {
+		return w
+	}
+	if g.Grid.isFull() {
+		return &Winner{Draw: true}
+	}
+	return nil
+}
+
+func (standardRules) LegalMoves(g *game) []*MoveRange {
+	return emptySquares(g.Grid)
+}
+
+// SetupBoard lets X move unless X has one mark more than Y. Other counts
+// cannot occur since X moves first.
+func (standardRules) SetupBoard(g *game) error {
+	var x, y int
+	for _, mark := range g.Grid.grid {
+		switch mark {
+		case Mark_X:
+			x++
+		case Mark_Y:
+			y++
+		}
+	}
+	switch x - y {
+	case 0:
+		g.CurrentPlayer = 0
+	case 1:
+		g.CurrentPlayer = 1
+	default:
+		return ErrInvalidBoard
+	}
+	return nil
+}
+
+func (standardRules) NearbyMoves() bool { return true }
+func (standardRules) Solvable() bool    { return true }
+
+// lineDirections are the directions of the lines through a square. Lines
+// run from left to right, vertical ones from top to bottom.
+var lineDirections = []struct {
+	direction Winner_Location_Direction
+	dx, dy    int
+}{
+	{Winner_Location_HORIZONTAL, 1, 0},
+	{Winner_Location_VERTICAL, 0, 1},
+	{Winner_Location_DIAGONAL_UP, 1, -1},
+	{Winner_Location_DIAGONAL_DOWN, 1, 1},
+}
+
+// lineWinner checks the lines going through (x, y) for k marks in a row.
+// Every completed line is reported with the full run of marks.
+func lineWinner(grid *gameGrid, k int, userID string, x, y int) *Winner {
+	w := &Winner{}
+	for _, d := range lineDirections {
+		start, end, length := grid.lineThrough(x, y, d.dx, d.dy)
+		if length < k {
+			continue
+		}
+		loc := &Winner_Location{
+			Direction: d.direction,
+			Start:     square(start[0], start[1]),
+			End:       square(end[0], end[1]),
+		}
+		switch d.direction {
+		case Winner_Location_HORIZONTAL:
+			loc.Position = int32(y)
+		case Winner_Location_VERTICAL:
+			loc.Position = int32(x)
+		}
+		w.Locations = append(w.Locations, loc)
+	}
+	if len(w.Locations) == 0 {
+		return nil
+	}
+	w.UserId = userID
+	return w
+}
+
+func square(x, y int) *TurnRequest_Square {
+	return &TurnRequest_Square{X: int32(x), Y: int32(y)}
+}
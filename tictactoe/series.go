@@ -0,0 +1,246 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc"
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/google.golang.org/grpc/codes"
+)
+
+var (
+	ErrSeriesNotFound = grpc.Errorf(codes.NotFound, "series not found")
+
+	ErrGameNotFinished = errors.New("game not finished")
+)
+
+// seriesScore is the result of the games of a series.
+type seriesScore struct {
+	Wins  map[string]int
+	Draws int
+}
+
+// add counts the result of a finished game. Games without a result do
+// not count.
+func (s seriesScore) add(w *Winner) seriesScore {
+	r := seriesScore{
+		Wins:  make(map[string]int, len(s.Wins)+1),
+		Draws: s.Draws,
+	}
+	for userID, wins := range s.Wins {
+		r.Wins[userID] = wins
+	}
+	switch {
+	case w == nil:
+	case w.Draw:
+		r.Draws += 1
+	case w.UserId != "":
+		r.Wins[w.UserId] += 1
+	}
+	return r
+}
+
+func scoreOf(s *Series) seriesScore {
+	score := seriesScore{
+		Wins:  make(map[string]int, len(s.Scores)),
+		Draws: int(s.Draws),
+	}
+	for _, sc := range s.Scores {
+		score.Wins[sc.UserId] = int(sc.Wins)
+	}
+	return score
+}
+
+// series returns the series of the game including the result of the game
+// itself if it is finished.
+func (g *game) series() *Series {
+	score := g.SeriesScore.add(g.winner())
+	s := &Series{
+		SeriesId:   g.SeriesID,
+		GameId:     string(g.ID),
+		GameNumber: int32(g.GameNumber),
+		Draws:      int32(score.Draws),
+	}
+	for _, userID := range g.PlayerList {
+		s.Scores = append(s.Scores, &Series_Score{
+			UserId: userID,
+			Wins:   int32(score.Wins[userID]),
+		})
+	}
+	return s
+}
+
+// seriesIndex maps every series to its latest game.
+type seriesIndex struct {
+	lock   sync.Mutex
+	latest map[string]seriesGame
+}
+
+type seriesGame struct {
+	ID     GameID
+	Number int
+}
+
+func newSeriesIndex() *seriesIndex {
+	return &seriesIndex{
+		latest: make(map[string]seriesGame),
+	}
+}
+
+func (idx *seriesIndex) add(g *game) {
+	if g.SeriesID == "" {
+		return
+	}
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if l, ok := idx.latest[g.SeriesID]; !ok || l.Number <= g.GameNumber {
+		idx.latest[g.SeriesID] = seriesGame{ID: g.ID, Number: g.GameNumber}
+	}
+}
+
+func (idx *seriesIndex) get(seriesID string) (GameID, bool) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	l, ok := idx.latest[seriesID]
+	return l.ID, ok
+}
+
+// remove forgets the series if g is its latest game.
+func (idx *seriesIndex) remove(g *game) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if l, ok := idx.latest[g.SeriesID]; ok && l.ID == g.ID {
+		delete(idx.latest, g.SeriesID)
+	}
+}
+
+// rematch creates the next game of the series with the same settings. The
+// turn order is rotated, so the player that moved second starts and the
+// marks move along with it.
+func (g *game) rematch(userID string) (*game, error) {
+	if _, ok := g.Players[userID]; !ok {
+		return nil, ErrNotPlayer
+	}
+	if !g.isFinished() {
+		return nil, ErrGameNotFinished
+	}
+	players := append(append([]string(nil), g.PlayerList[1:]...), g.PlayerList[0])
+	r, err := newGame(newID(), g.Variant, players, g.Grid.width, g.Grid.height, g.WinLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setTimeControl(g.TimeControl); err != nil {
+		return nil, err
+	}
+	if err := r.setBots(g.botSeats()); err != nil {
+		return nil, err
+	}
+	r.SeriesID = g.SeriesID
+	r.GameNumber = g.GameNumber + 1
+	r.SeriesScore = g.SeriesScore.add(g.winner())
+	return r, nil
+}
+
+func (m *GameManager) Rematch(ctx context.Context, req *RematchRequest) (*RematchReply, error) {
+	var rep RematchReply
+
+	entry, err := m.lockGame(GameID(req.GameId))
+	if err == ErrGameNotFound {
+		rep.Status = RematchReply_GAME_NOT_FOUND
+		return &rep, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer entry.lock.Unlock()
+
+	if entry.game.RematchID != "" {
+		rep.Status = RematchReply_ALREADY_REMATCHED
+		rep.GameId = string(entry.game.RematchID)
+		return &rep, nil
+	}
+	g := entry.game.clone()
+	// Games created before series were introduced start a new series.
+	if g.SeriesID == "" {
+		g.SeriesID = string(newID())
+		g.GameNumber = 1
+	}
+	r, err := g.rematch(req.UserId)
+	switch {
+	case err == ErrNotPlayer:
+		rep.Status = RematchReply_NOT_PLAYER
+		return &rep, nil
+	case err == ErrGameNotFinished:
+		rep.Status = RematchReply_NOT_FINISHED
+		return &rep, nil
+	case err != nil:
+		return nil, err
+	}
+
+	// Both games are saved before any of their events is sent, so a
+	// rematch that cannot be linked to the game is never announced.
+	rEntry := m.games.add(r)
+	defer rEntry.lock.Unlock()
+	created := createdEvent(r)
+	if err := m.save(r, created); err != nil {
+		m.discardGame(r.ID)
+		return nil, err
+	}
+	g.RematchID = r.ID
+	ev := &Event{
+		Type:          Event_REMATCH_CREATED,
+		Timestamp:     time.Now().UnixNano(),
+		GameId:        req.GameId,
+		UserId:        req.UserId,
+		UserList:      g.PlayerList,
+		RematchGameId: string(r.ID),
+		Series:        g.series(),
+	}
+	if err := m.save(g, ev); err != nil {
+		m.discardGame(r.ID)
+		return nil, err
+	}
+	m.install(rEntry, r, created)
+	m.series.add(r)
+	m.install(entry, g, ev)
+
+	rep.GameId = string(r.ID)
+	return &rep, nil
+}
+
+func (m *GameManager) GetSeries(ctx context.Context, req *GetSeriesRequest) (*GetSeriesReply, error) {
+	id, ok := m.series.get(req.SeriesId)
+	if !ok {
+		return nil, ErrSeriesNotFound
+	}
+	entry, ok := m.games.get(id)
+	if !ok {
+		return nil, ErrSeriesNotFound
+	}
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if entry.game.Expired {
+		return nil, ErrSeriesNotFound
+	}
+	return &GetSeriesReply{Series: entry.game.series()}, nil
+}
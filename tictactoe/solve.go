@@ -0,0 +1,126 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"sync"
+
+	"github.com/protogalaxy/service-tictactoe-game/solver"
+)
+
+const (
+	// Positions the solver may search before analysis and bots fall back
+	// to a depth limited search.
+	solverNodeLimit = 1 << 20
+	solverTableSize = 1 << 18
+	// Positions with more empty squares are not given to the solver since
+	// it does not finish them within the node limit.
+	solverMaxEmptySquares = 16
+)
+
+// solverPools keeps idle solvers per board size, so that the positions a
+// solver found are reused by later searches without searches waiting for
+// each other.
+type solverPools struct {
+	lock  sync.Mutex
+	pools map[[3]int]*sync.Pool
+}
+
+var solvers = &solverPools{pools: make(map[[3]int]*sync.Pool)}
+
+func (p *solverPools) pool(width, height, winLength int) *sync.Pool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	size := [3]int{width, height, winLength}
+	if pool, ok := p.pools[size]; ok {
+		return pool
+	}
+	pool := &sync.Pool{}
+	p.pools[size] = pool
+	return pool
+}
+
+// get returns an idle solver for the board size or a new one. It has to be
+// handed back with put.
+func (p *solverPools) get(width, height, winLength int) (*solver.Solver, error) {
+	if s, ok := p.pool(width, height, winLength).Get().(*solver.Solver); ok {
+		return s, nil
+	}
+	s, err := solver.New(width, height, winLength)
+	if err != nil {
+		return nil, err
+	}
+	s.SetTableSize(solverTableSize)
+	s.SetNodeLimit(solverNodeLimit)
+	return s, nil
+}
+
+func (p *solverPools) put(width, height, winLength int, s *solver.Solver) {
+	p.pool(width, height, winLength).Put(s)
+}
+
+// solveMoves solves every legal move of the active player. It fails for
+// variants the solver cannot solve, for games with more than two players,
+// for positions with too many empty squares and when the node limit is
+// hit.
+func solveMoves(g *game) ([]solver.Move, solver.Stats, bool) {
+	if !solvable(g) || len(g.PlayerList) != 2 {
+		return nil, solver.Stats{}, false
+	}
+	if g.Grid.countEmpty() > solverMaxEmptySquares {
+		return nil, solver.Stats{}, false
+	}
+	b, err := solver.NewBoard(g.Grid.width, g.Grid.height, g.WinLength)
+	if err != nil {
+		return nil, solver.Stats{}, false
+	}
+	for i, mark := range g.Grid.grid {
+		if mark != Mark_EMPTY {
+			b.Set(i%g.Grid.width, i/g.Grid.width, solver.Player(mark-Mark_X+1))
+		}
+	}
+	s, err := solvers.get(g.Grid.width, g.Grid.height, g.WinLength)
+	if err != nil {
+		return nil, solver.Stats{}, false
+	}
+	defer solvers.put(g.Grid.width, g.Grid.height, g.WinLength, s)
+	moves, err := s.Moves(b, solver.Player(g.CurrentPlayer+1))
+	return moves, s.Stats(), err == nil
+}
+
+// solvedScores returns the minimax scores of the given squares for the
+// active player as found by the solver.
+func solvedScores(g *game, squares [][2]int) ([]int, solver.Stats, bool) {
+	moves, stats, ok := solveMoves(g)
+	if !ok {
+		return nil, stats, false
+	}
+	byIndex := make(map[int]solver.Result, len(moves))
+	for _, m := range moves {
+		byIndex[m.Y*g.Grid.width+m.X] = m.Result
+	}
+	scores := make([]int, len(squares))
+	for i, sq := range squares {
+		r := byIndex[sq[1]*g.Grid.width+sq[0]]
+		switch r.Outcome {
+		case solver.Win:
+			scores[i] = minimaxWinScore - r.Distance
+		case solver.Loss:
+			scores[i] = -minimaxWinScore + r.Distance
+		}
+	}
+	return scores, stats, true
+}
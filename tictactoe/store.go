@@ -0,0 +1,336 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	gameFileExt  = ".game"
+	outboxDirExt = ".outbox"
+	eventFileExt = ".event"
+)
+
+var (
+	ErrGameNotStored = errors.New("game not stored")
+	ErrInvalidGameID = errors.New("invalid game id")
+)
+
+// GameStore persists encoded games.
+type GameStore interface {
+	Save(id GameID, data []byte) error
+	Load(id GameID) ([]byte, error)
+	List() ([]GameID, error)
+	// Delete removes the game and its outbox.
+	Delete(id GameID) error
+
+	// The outbox of a game holds the encoded events that were not
+	// published yet by their sequence number. Events are only ever added
+	// and deleted, so recording an event does not rewrite the others.
+	AppendEvent(id GameID, seq int64, data []byte) error
+	DeleteEvent(id GameID, seq int64) error
+	// LoadEvents returns the outbox of the game in sequence order.
+	LoadEvents(id GameID) ([][]byte, error)
+}
+
+type MemoryStore struct {
+	lock     sync.RWMutex
+	games    map[GameID][]byte
+	outboxes map[GameID]map[int64][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		games:    make(map[GameID][]byte),
+		outboxes: make(map[GameID]map[int64][]byte),
+	}
+}
+
+func (s *MemoryStore) Save(id GameID, data []byte) error {
+	b := make([]byte, len(data))
+	copy(b, data)
+
+	s.lock.Lock()
+	s.games[id] = b
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Load(id GameID) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b, ok := s.games[id]
+	if !ok {
+		return nil, ErrGameNotStored
+	}
+	return b, nil
+}
+
+func (s *MemoryStore) List() ([]GameID, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ids := make([]GameID, 0, len(s.games))
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *MemoryStore) Delete(id GameID) error {
+	s.lock.Lock()
+	delete(s.games, id)
+	delete(s.outboxes, id)
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) AppendEvent(id GameID, seq int64, data []byte) error {
+	b := make([]byte, len(data))
+	copy(b, data)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.outboxes[id] == nil {
+		s.outboxes[id] = make(map[int64][]byte)
+	}
+	s.outboxes[id][seq] = b
+	return nil
+}
+
+func (s *MemoryStore) DeleteEvent(id GameID, seq int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.outboxes[id], seq)
+	if len(s.outboxes[id]) == 0 {
+		delete(s.outboxes, id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) LoadEvents(id GameID) ([][]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	seqs := make(sequences, 0, len(s.outboxes[id]))
+	for seq := range s.outboxes[id] {
+		seqs = append(seqs, seq)
+	}
+	sort.Sort(seqs)
+	events := make([][]byte, 0, len(seqs))
+	for _, seq := range seqs {
+		events = append(events, s.outboxes[id][seq])
+	}
+	return events, nil
+}
+
+type sequences []int64
+
+func (s sequences) Len() int           { return len(s) }
+func (s sequences) Less(i, j int) bool { return s[i] < s[j] }
+func (s sequences) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// FileStore keeps every game in its own file in a directory. Files are
+// replaced atomically so a crash never leaves a partially written game.
+// The outbox of a game is a directory next to it with a file per event.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(id GameID, ext string) (string, error) {
+	name := string(id)
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidGameID
+	}
+	return filepath.Join(s.dir, name+ext), nil
+}
+
+func (s *FileStore) eventPath(id GameID, seq int64) (string, error) {
+	dir, err := s.path(id, outboxDirExt)
+	if err != nil {
+		return "", err
+	}
+	// Zero padded so the directory lists the events in sequence order.
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", seq, eventFileExt)), nil
+}
+
+func (s *FileStore) Save(id GameID, data []byte) error {
+	path, err := s.path(id, gameFileExt)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile replaces the file with the data by renaming a temporary file
+// that was synced to disk.
+func writeFile(path string, data []byte) error {
+	dir, name := filepath.Split(path)
+	f, err := ioutil.TempFile(dir, "."+name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *FileStore) Load(id GameID) ([]byte, error) {
+	path, err := s.path(id, gameFileExt)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrGameNotStored
+	}
+	return b, err
+}
+
+func (s *FileStore) List() ([]GameID, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []GameID
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, gameFileExt) {
+			continue
+		}
+		ids = append(ids, GameID(strings.TrimSuffix(name, gameFileExt)))
+	}
+	return ids, nil
+}
+
+func (s *FileStore) Delete(id GameID) error {
+	path, err := s.path(id, gameFileExt)
+	if err != nil {
+		return err
+	}
+	dir, err := s.path(id, outboxDirExt)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) AppendEvent(id GameID, seq int64, data []byte) error {
+	path, err := s.eventPath(id, seq)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+func (s *FileStore) DeleteEvent(id GameID, seq int64) error {
+	path, err := s.eventPath(id, seq)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) LoadEvents(id GameID) ([][]byte, error) {
+	dir, err := s.path(id, outboxDirExt)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var events [][]byte
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, eventFileExt) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, b)
+	}
+	return events, nil
+}
+
+// encodeGame encodes the game without its outbox, which is stored event by
+// event.
+func encodeGame(g *game) ([]byte, error) {
+	c := *g
+	c.Outbox = nil
+	return json.Marshal(&c)
+}
+
+func decodeGame(b []byte) (*game, error) {
+	var g game
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, err
+	}
+	if _, err := lookupRuleset(g.Variant); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
@@ -0,0 +1,437 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// Depth limit of the minimax search on boards that are too large to
+	// search to the end.
+	minimaxFullSearchSquares = 12
+	minimaxDefaultDepth      = 4
+	// On boards with more legal moves only the squares within this
+	// distance of a mark are searched.
+	minimaxNeighbourhood = 2
+
+	mctsDefaultIterations = 1000
+	mctsExploration       = 1.4
+
+	// searchTimeLimit is how long a bot may think about a move.
+	searchTimeLimit = 500 * time.Millisecond
+
+	// Largest settings a bot seat may ask for.
+	maxBotDepth      = 12
+	maxBotIterations = 100000
+)
+
+func init() {
+	RegisterStrategy("random", func(seat *BotSeat) (Strategy, error) {
+		return randomStrategy{}, nil
+	})
+	RegisterStrategy("greedy", func(seat *BotSeat) (Strategy, error) {
+		return greedyStrategy{}, nil
+	})
+	RegisterStrategy("minimax", func(seat *BotSeat) (Strategy, error) {
+		if seat.Depth < 0 || seat.Depth > maxBotDepth || seat.ErrorRate < 0 || seat.ErrorRate > 1 {
+			return nil, ErrInvalidBotSettings
+		}
+		return minimaxStrategy{depth: int(seat.Depth), errorRate: seat.ErrorRate}, nil
+	})
+	RegisterStrategy("mcts", func(seat *BotSeat) (Strategy, error) {
+		if seat.Iterations < 0 || seat.Iterations > maxBotIterations {
+			return nil, ErrInvalidBotSettings
+		}
+		iterations := int(seat.Iterations)
+		if iterations == 0 {
+			iterations = mctsDefaultIterations
+		}
+		return mctsStrategy{iterations: iterations}, nil
+	})
+}
+
+// randomStrategy plays a random legal move.
+type randomStrategy struct{}
+
+func (randomStrategy) Move(g *game, userID string) (int, int) {
+	squares := legalSquares(g)
+	sq := squares[rand.Intn(len(squares))]
+	return sq[0], sq[1]
+}
+
+// greedyStrategy wins if it can and blocks the opponent from winning on
+// their next move. Otherwise it plays a random move that does not lose
+// right away. Wins and losses are decided by the rules of the variant, so
+// in misère games the bot stays away from completing a line.
+type greedyStrategy struct{}
+
+func (greedyStrategy) Move(g *game, userID string) (int, int) {
+	squares := legalSquares(g)
+	if sq, ok := winningSquare(g, userID, squares); ok {
+		return sq[0], sq[1]
+	}
+	if sq, ok := winningSquare(g, g.opponent(userID), squares); ok {
+		return sq[0], sq[1]
+	}
+	var safe [][2]int
+	for _, sq := range squares {
+		if w := moveOutcome(g, userID, sq); w == nil || w.Draw || w.UserId == userID {
+			safe = append(safe, sq)
+		}
+	}
+	if len(safe) > 0 {
+		squares = safe
+	}
+	sq := squares[rand.Intn(len(squares))]
+	return sq[0], sq[1]
+}
+
+// winningSquare returns a square on which the player would win.
+func winningSquare(g *game, userID string, squares [][2]int) ([2]int, bool) {
+	for _, sq := range squares {
+		if w := moveOutcome(g, userID, sq); w != nil && w.UserId == userID {
+			return sq, true
+		}
+	}
+	return [2]int{}, false
+}
+
+// moveOutcome returns the result of the game after the player moved on
+// the square or nil if the game goes on.
+func moveOutcome(g *game, userID string, sq [2]int) *Winner {
+	c := g.searchCopy()
+	if err := c.applyMove(userID, sq[0], sq[1]); err != nil {
+		return nil
+	}
+	return c.Winner
+}
+
+// minimaxStrategy searches the game tree with negamax and alpha-beta
+// pruning. With a depth of 0 positions the solver can handle are solved
+// and other small boards are searched to the end. The
+// error rate is the chance of playing a random move instead.
+type minimaxStrategy struct {
+	depth     int
+	errorRate float64
+}
+
+const minimaxWinScore = 1000
+
+func (s minimaxStrategy) Move(g *game, userID string) (int, int) {
+	squares := legalSquares(g)
+	if s.errorRate > 0 && rand.Float64() < s.errorRate {
+		sq := squares[rand.Intn(len(squares))]
+		return sq[0], sq[1]
+	}
+	// negamax assumes that the opponent moves next.
+	if len(g.playersInPlay()) > 2 {
+		return mctsStrategy{iterations: mctsDefaultIterations}.Move(g, userID)
+	}
+	depth := s.depth
+	if depth == 0 {
+		if scores, _, ok := solvedScores(g, squares); ok {
+			best := 0
+			for i, sq := range squares {
+				if scores[i] > scores[best] || scores[i] == scores[best] && centerDistance(g.Grid, sq) < centerDistance(g.Grid, squares[best]) {
+					best = i
+				}
+			}
+			return squares[best][0], squares[best][1]
+		}
+		depth, _ = searchDepth(g)
+	}
+
+	// Search one ply deeper at a time with the best move of the last
+	// depth first, until the depth is reached or the time is up. A depth
+	// that was not searched completely is ignored.
+	squares = searchSquares(g)
+	budget := newSearchBudget(searchTimeLimit)
+	best := squares[0]
+	for d := 1; d <= depth; d++ {
+		sq, score, ok := searchRoot(g, userID, squares, d, budget)
+		if !ok {
+			break
+		}
+		best = sq
+		moveToFront(squares, sq)
+		if score > minimaxWinScore/2 {
+			break
+		}
+	}
+	return best[0], best[1]
+}
+
+// searchRoot searches the squares to the given depth and returns the best
+// one with its score. It fails if the budget runs out.
+func searchRoot(g *game, userID string, squares [][2]int, depth int, budget *searchBudget) ([2]int, int, bool) {
+	best, bestScore := squares[0], -minimaxWinScore-1
+	alpha, beta := -minimaxWinScore-1, minimaxWinScore+1
+	for _, sq := range squares {
+		c := g.searchCopy()
+		c.applyMove(userID, sq[0], sq[1])
+		// The window is widened by one so that ties with the best move
+		// are exact.
+		score := -negamax(c, depth-1, -beta, -alpha+1, 1, budget)
+		if budget.exhausted() {
+			return best, bestScore, false
+		}
+		// Prefer central squares among equally good moves.
+		if score > bestScore || score == bestScore && centerDistance(g.Grid, sq) < centerDistance(g.Grid, best) {
+			best, bestScore = sq, score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return best, bestScore, true
+}
+
+func moveToFront(squares [][2]int, sq [2]int) {
+	for i := range squares {
+		if squares[i] == sq {
+			copy(squares[1:i+1], squares[:i])
+			squares[0] = sq
+			return
+		}
+	}
+}
+
+// searchDepth returns the depth of a minimax search and whether it reaches
+// the end of the game. Games with few empty squares left are searched to
+// the end.
+func searchDepth(g *game) (int, bool) {
+	empty := g.Grid.countEmpty()
+	if empty > minimaxFullSearchSquares {
+		return minimaxDefaultDepth, false
+	}
+	return empty, true
+}
+
+// negamax returns the score of the game for the active player. Faster
+// wins and slower losses score higher. Once the budget is spent it returns
+// 0 without searching.
+func negamax(g *game, depth, alpha, beta, ply int, budget *searchBudget) int {
+	if !budget.spend() {
+		return 0
+	}
+	if g.isFinished() {
+		if g.Winner.Draw || g.Winner.UserId == "" {
+			return 0
+		}
+		// The winner is usually the player that moved last but in misère
+		// games it is the player to move.
+		if g.Winner.UserId == g.PlayerList[g.CurrentPlayer] {
+			return minimaxWinScore - ply
+		}
+		return -minimaxWinScore + ply
+	}
+	if depth == 0 {
+		return 0
+	}
+	userID := g.activePlayer()
+	best := -minimaxWinScore - 1
+	for _, sq := range searchSquares(g) {
+		c := g.searchCopy()
+		c.applyMove(userID, sq[0], sq[1])
+		score := -negamax(c, depth-1, -beta, -alpha, ply+1, budget)
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// searchBudget stops a search once its time is up. The clock is only read
+// every searchBudgetCheck positions. A budget without a deadline never
+// runs out.
+type searchBudget struct {
+	nodes    uint64
+	deadline time.Time
+	spent    bool
+}
+
+const searchBudgetCheck = 256
+
+func newSearchBudget(d time.Duration) *searchBudget {
+	return &searchBudget{deadline: time.Now().Add(d)}
+}
+
+// spend counts a position and reports whether there is time to search it.
+func (b *searchBudget) spend() bool {
+	if b.spent {
+		return false
+	}
+	b.nodes++
+	if b.nodes%searchBudgetCheck == 0 && !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		b.spent = true
+	}
+	return !b.spent
+}
+
+func (b *searchBudget) exhausted() bool {
+	return b.spent
+}
+
+// searchSquares returns the legal squares in the order they are searched.
+// With many legal moves only the squares near marks are kept, or the most
+// central ones on an empty board. Variants whose moves can mark another
+// square than the chosen one keep all of their moves.
+func searchSquares(g *game) [][2]int {
+	squares := legalSquares(g)
+	if len(squares) > minimaxFullSearchSquares && nearbyMoves(g) {
+		var near, central [][2]int
+		for _, sq := range squares {
+			if marksAround(g.Grid, sq, minimaxNeighbourhood) > 0 {
+				near = append(near, sq)
+			}
+			switch {
+			case len(central) == 0 || centerDistance(g.Grid, sq) < centerDistance(g.Grid, central[0]):
+				central = [][2]int{sq}
+			case centerDistance(g.Grid, sq) == centerDistance(g.Grid, central[0]):
+				central = append(central, sq)
+			}
+		}
+		squares = near
+		if len(near) == 0 {
+			squares = central
+		}
+	}
+	sort.Stable(byContact{g.Grid, squares})
+	return squares
+}
+
+// marksAround counts the marks within distance d of the square.
+func marksAround(grid *gameGrid, sq [2]int, d int) int {
+	n := 0
+	for y := sq[1] - d; y <= sq[1]+d; y++ {
+		for x := sq[0] - d; x <= sq[0]+d; x++ {
+			if grid.coordinatesValid(x, y) && !grid.isEmpty(x, y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// byContact orders squares with more marks next to them first, so that
+// alpha-beta pruning sees the contested squares early.
+type byContact struct {
+	grid    *gameGrid
+	squares [][2]int
+}
+
+func (s byContact) Len() int      { return len(s.squares) }
+func (s byContact) Swap(i, j int) { s.squares[i], s.squares[j] = s.squares[j], s.squares[i] }
+func (s byContact) Less(i, j int) bool {
+	return marksAround(s.grid, s.squares[i], 1) > marksAround(s.grid, s.squares[j], 1)
+}
+
+// mctsStrategy runs Monte Carlo tree search with UCT selection and random
+// playouts. It suits boards that are too large for minimax.
+type mctsStrategy struct {
+	iterations int
+}
+
+type mctsNode struct {
+	parent   *mctsNode
+	square   [2]int
+	mover    string
+	children []*mctsNode
+	untried  [][2]int
+	visits   int
+	// score counts wins of the mover as 1 and draws as 0.5.
+	score float64
+}
+
+// Move stops early if the iterations take longer than the search time
+// limit. Every move of a playout counts as a searched position.
+func (s mctsStrategy) Move(g *game, userID string) (int, int) {
+	root := &mctsNode{untried: legalSquares(g)}
+	budget := newSearchBudget(searchTimeLimit)
+	for i := 0; i < s.iterations && !budget.exhausted(); i++ {
+		n, c := root, g.searchCopy()
+		// Select a leaf by UCT.
+		for len(n.untried) == 0 && len(n.children) > 0 {
+			n = n.selectChild()
+			c.applyMove(n.mover, n.square[0], n.square[1])
+		}
+		// Expand one untried move.
+		if len(n.untried) > 0 && !c.isFinished() {
+			k := rand.Intn(len(n.untried))
+			sq := n.untried[k]
+			n.untried[k] = n.untried[len(n.untried)-1]
+			n.untried = n.untried[:len(n.untried)-1]
+
+			mover := c.activePlayer()
+			c.applyMove(mover, sq[0], sq[1])
+			child := &mctsNode{parent: n, square: sq, mover: mover}
+			if !c.isFinished() {
+				child.untried = legalSquares(c)
+			}
+			n.children = append(n.children, child)
+			n = child
+		}
+		// Play out randomly and propagate the result.
+		for !c.isFinished() && budget.spend() {
+			squares := legalSquares(c)
+			sq := squares[rand.Intn(len(squares))]
+			c.applyMove(c.activePlayer(), sq[0], sq[1])
+		}
+		if budget.exhausted() {
+			break
+		}
+		for ; n != nil; n = n.parent {
+			n.visits += 1
+			switch {
+			case c.Winner.Draw || c.Winner.UserId == "":
+				n.score += 0.5
+			case c.Winner.UserId == n.mover:
+				n.score += 1
+			}
+		}
+	}
+
+	best := root.children[0]
+	for _, child := range root.children[1:] {
+		if child.visits > best.visits {
+			best = child
+		}
+	}
+	return best.square[0], best.square[1]
+}
+
+func (n *mctsNode) selectChild() *mctsNode {
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, child := range n.children {
+		v := child.score/float64(child.visits) + mctsExploration*math.Sqrt(logVisits/float64(child.visits))
+		if v > bestValue {
+			best, bestValue = child, v
+		}
+	}
+	return best
+}
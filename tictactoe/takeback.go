@@ -0,0 +1,191 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"time"
+
+	"github.com/protogalaxy/service-tictactoe-game/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+var (
+	ErrTakebackRequested = errors.New("takeback already requested")
+	ErrInvalidPlies      = errors.New("invalid number of plies to take back")
+	ErrNoTakeback        = errors.New("no takeback to respond to")
+)
+
+type takebackRequest struct {
+	UserID string
+	Plies  int
+}
+
+func (g *game) moves() []*PlayedMove {
+	moves := make([]*PlayedMove, 0, len(g.Moves))
+	for _, mv := range g.Moves {
+		moves = append(moves, &PlayedMove{
+			UserId: mv.UserID,
+			Square: g.squareAt(mv.X, mv.Y),
+		})
+	}
+	return moves
+}
+
+// checkTakeback allows takebacks in running games and in games that were
+// decided on the board. A game that was followed by a rematch stays
+// finished, its result counts in the series.
+func (g *game) checkTakeback(userID string) error {
+	if _, ok := g.Players[userID]; !ok {
+		return ErrNotPlayer
+	}
+	if g.isFinished() && (!decidedOnBoard(g.Winner) || g.RematchID != "") {
+		return ErrGameFinished
+	}
+	return nil
+}
+
+// decidedOnBoard reports whether the result came from the rules of the
+// variant rather than from players leaving the game or agreeing to a draw.
+func decidedOnBoard(w *Winner) bool {
+	switch w.Reason {
+	case Winner_RESIGNATION, Winner_TIMEOUT, Winner_ABORT, Winner_AGREEMENT:
+		return false
+	}
+	return true
+}
+
+func (g *game) requestTakeback(userID string, plies int) error {
+	if err := g.checkTakeback(userID); err != nil {
+		return err
+	}
+	if len(g.PlayerList) > 2 {
+		return ErrTooManyPlayers
+	}
+	if g.Takeback != nil {
+		return ErrTakebackRequested
+	}
+	// Games that were started before moves were recorded have an
+	// incomplete move list.
+	if plies < 1 || plies > len(g.Moves) || len(g.Moves) != g.TurnNumber {
+		return ErrInvalidPlies
+	}
+	g.Takeback = &takebackRequest{UserID: userID, Plies: plies}
+	return nil
+}
+
+// respondToTakeback accepts or declines the takeback the opponent asked
+// for. It returns the number of plies taken back.
+func (g *game) respondToTakeback(userID string, accept bool) (int, error) {
+	if err := g.checkTakeback(userID); err != nil {
+		return 0, err
+	}
+	if g.Takeback == nil || g.Takeback.UserID == userID {
+		return 0, ErrNoTakeback
+	}
+	plies := g.Takeback.Plies
+	g.Takeback = nil
+	if !accept {
+		return 0, nil
+	}
+	return plies, g.takeBack(plies)
+}
+
+// takeBack removes the last plies from the move list and rebuilds the game
+// by playing the remaining moves on an empty board.
+func (g *game) takeBack(plies int) error {
+	if plies < 1 || plies > len(g.Moves) || len(g.Moves) != g.TurnNumber {
+		return ErrInvalidPlies
+	}
+	moves := g.Moves[:len(g.Moves)-plies]
+	if err := g.rules().Setup(g, g.Grid.width, g.Grid.height, g.WinLength); err != nil {
+		return err
+	}
+	g.Moves = nil
+	g.Winner = nil
+	g.FinishedTimestamp = 0
+	g.CurrentPlayer = 0
+	g.TurnNumber = 0
+	for _, mv := range moves {
+		if err := g.applyMove(mv.UserID, mv.X, mv.Y); err != nil {
+			return err
+		}
+	}
+	g.TurnTimestamp = time.Now().UnixNano()
+	return nil
+}
+
+func (m *GameManager) RequestTakeback(ctx context.Context, req *TakebackRequest) (*TakebackReply, error) {
+	plies := int(req.Plies)
+	if plies == 0 {
+		plies = 1
+	}
+	var rep TakebackReply
+	err := m.playerAction(req.GameId, req.UserId, Event_TAKEBACK_REQUESTED, func(g *game, ev *Event) error {
+		ev.Plies = int32(plies)
+		return g.requestTakeback(req.UserId, plies)
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = TakebackReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = TakebackReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = TakebackReply_FINISHED
+	case err == ErrTakebackRequested:
+		rep.Status = TakebackReply_ALREADY_REQUESTED
+	case err == ErrInvalidPlies:
+		rep.Status = TakebackReply_INVALID_PLIES
+	case err == ErrTooManyPlayers:
+		rep.Status = TakebackReply_TOO_MANY_PLAYERS
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (m *GameManager) RespondToTakeback(ctx context.Context, req *RespondToTakebackRequest) (*RespondToTakebackReply, error) {
+	t := Event_TAKEBACK_DECLINED
+	if req.Accept {
+		t = Event_MOVES_TAKEN_BACK
+	}
+	var rep RespondToTakebackReply
+	err := m.playerAction(req.GameId, req.UserId, t, func(g *game, ev *Event) error {
+		plies, err := g.respondToTakeback(req.UserId, req.Accept)
+		if err != nil {
+			return err
+		}
+		ev.Plies = int32(plies)
+		if plies > 0 {
+			ev.NextPlayer = g.activePlayer()
+			ev.ValidMoves = g.validMoves()
+			ev.SubBoards = g.subBoardResults()
+		}
+		return nil
+	})
+	switch {
+	case err == ErrGameNotFound:
+		rep.Status = RespondToTakebackReply_GAME_NOT_FOUND
+	case err == ErrNotPlayer:
+		rep.Status = RespondToTakebackReply_NOT_PLAYER
+	case err == ErrGameFinished:
+		rep.Status = RespondToTakebackReply_FINISHED
+	case err == ErrNoTakeback:
+		rep.Status = RespondToTakebackReply_NO_REQUEST
+	case err != nil:
+		return nil, err
+	}
+	return &rep, nil
+}
@@ -9,12 +9,43 @@ It is generated from these files:
 	tictactoe.proto
 
 It has these top-level messages:
+	TimeControl
+	Clock
+	BotSeat
 	CreateRequest
 	CreateReply
+	SubBoard
 	TurnRequest
 	Winner
+	SubBoardResult
 	TurnReply
 	MoveRange
+	PlayedMove
+	GetGameRequest
+	GameState
+	GetGameReply
+	WatchRequest
+	WatchReply
+	ResignRequest
+	ResignReply
+	AbortRequest
+	AbortReply
+	OfferDrawRequest
+	OfferDrawReply
+	RespondToDrawRequest
+	RespondToDrawReply
+	TakebackRequest
+	TakebackReply
+	RespondToTakebackRequest
+	RespondToTakebackReply
+	Series
+	RematchRequest
+	RematchReply
+	GetSeriesRequest
+	GetSeriesReply
+	AnalyzeRequest
+	MoveAnalysis
+	AnalyzeReply
 	Event
 */
 package tictactoe
@@ -39,23 +70,49 @@ const (
 	Mark_EMPTY Mark = 0
 	Mark_X     Mark = 1
 	Mark_Y     Mark = 2
+	Mark_Z     Mark = 3
+	Mark_W     Mark = 4
 )
 
 var Mark_name = map[int32]string{
 	0: "EMPTY",
 	1: "X",
 	2: "Y",
+	3: "Z",
+	4: "W",
 }
 var Mark_value = map[string]int32{
 	"EMPTY": 0,
 	"X":     1,
 	"Y":     2,
+	"Z":     3,
+	"W":     4,
 }
 
 func (x Mark) String() string {
 	return proto.EnumName(Mark_name, int32(x))
 }
 
+type CreateRequest_TurnOrder int32
+
+const (
+	CreateRequest_LISTED CreateRequest_TurnOrder = 0
+	CreateRequest_RANDOM CreateRequest_TurnOrder = 1
+)
+
+var CreateRequest_TurnOrder_name = map[int32]string{
+	0: "LISTED",
+	1: "RANDOM",
+}
+var CreateRequest_TurnOrder_value = map[string]int32{
+	"LISTED": 0,
+	"RANDOM": 1,
+}
+
+func (x CreateRequest_TurnOrder) String() string {
+	return proto.EnumName(CreateRequest_TurnOrder_name, int32(x))
+}
+
 type CreateReply_ResponseStatus int32
 
 const (
@@ -99,6 +156,39 @@ func (x Winner_Location_Direction) String() string {
 	return proto.EnumName(Winner_Location_Direction_name, int32(x))
 }
 
+// Reason the game ended. A draw on a full board is reported as LINE.
+type Winner_Reason int32
+
+const (
+	Winner_LINE        Winner_Reason = 0
+	Winner_RESIGNATION Winner_Reason = 1
+	Winner_TIMEOUT     Winner_Reason = 2
+	Winner_ABORT       Winner_Reason = 3
+	Winner_AGREEMENT   Winner_Reason = 4
+	Winner_LOSING_LINE Winner_Reason = 5
+)
+
+var Winner_Reason_name = map[int32]string{
+	0: "LINE",
+	1: "RESIGNATION",
+	2: "TIMEOUT",
+	3: "ABORT",
+	4: "AGREEMENT",
+	5: "LOSING_LINE",
+}
+var Winner_Reason_value = map[string]int32{
+	"LINE":        0,
+	"RESIGNATION": 1,
+	"TIMEOUT":     2,
+	"ABORT":       3,
+	"AGREEMENT":   4,
+	"LOSING_LINE": 5,
+}
+
+func (x Winner_Reason) String() string {
+	return proto.EnumName(Winner_Reason_name, int32(x))
+}
+
 type TurnReply_ResponseStatus int32
 
 const (
@@ -107,6 +197,7 @@ const (
 	TurnReply_NOT_ACTIVE_PLAYER TurnReply_ResponseStatus = 2
 	TurnReply_FINISHED          TurnReply_ResponseStatus = 3
 	TurnReply_INVALID_MOVE_ID   TurnReply_ResponseStatus = 4
+	TurnReply_GAME_NOT_FOUND    TurnReply_ResponseStatus = 5
 )
 
 var TurnReply_ResponseStatus_name = map[int32]string{
@@ -115,6 +206,7 @@ var TurnReply_ResponseStatus_name = map[int32]string{
 	2: "NOT_ACTIVE_PLAYER",
 	3: "FINISHED",
 	4: "INVALID_MOVE_ID",
+	5: "GAME_NOT_FOUND",
 }
 var TurnReply_ResponseStatus_value = map[string]int32{
 	"SUCCESS":           0,
@@ -122,49 +214,415 @@ var TurnReply_ResponseStatus_value = map[string]int32{
 	"NOT_ACTIVE_PLAYER": 2,
 	"FINISHED":          3,
 	"INVALID_MOVE_ID":   4,
+	"GAME_NOT_FOUND":    5,
 }
 
 func (x TurnReply_ResponseStatus) String() string {
 	return proto.EnumName(TurnReply_ResponseStatus_name, int32(x))
 }
 
+type ResignReply_ResponseStatus int32
+
+const (
+	ResignReply_SUCCESS        ResignReply_ResponseStatus = 0
+	ResignReply_NOT_PLAYER     ResignReply_ResponseStatus = 1
+	ResignReply_FINISHED       ResignReply_ResponseStatus = 2
+	ResignReply_GAME_NOT_FOUND ResignReply_ResponseStatus = 3
+)
+
+var ResignReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+}
+var ResignReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":        0,
+	"NOT_PLAYER":     1,
+	"FINISHED":       2,
+	"GAME_NOT_FOUND": 3,
+}
+
+func (x ResignReply_ResponseStatus) String() string {
+	return proto.EnumName(ResignReply_ResponseStatus_name, int32(x))
+}
+
+type AbortReply_ResponseStatus int32
+
+const (
+	AbortReply_SUCCESS        AbortReply_ResponseStatus = 0
+	AbortReply_NOT_PLAYER     AbortReply_ResponseStatus = 1
+	AbortReply_FINISHED       AbortReply_ResponseStatus = 2
+	AbortReply_GAME_NOT_FOUND AbortReply_ResponseStatus = 3
+)
+
+var AbortReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+}
+var AbortReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":        0,
+	"NOT_PLAYER":     1,
+	"FINISHED":       2,
+	"GAME_NOT_FOUND": 3,
+}
+
+func (x AbortReply_ResponseStatus) String() string {
+	return proto.EnumName(AbortReply_ResponseStatus_name, int32(x))
+}
+
+type OfferDrawReply_ResponseStatus int32
+
+const (
+	OfferDrawReply_SUCCESS          OfferDrawReply_ResponseStatus = 0
+	OfferDrawReply_NOT_PLAYER       OfferDrawReply_ResponseStatus = 1
+	OfferDrawReply_FINISHED         OfferDrawReply_ResponseStatus = 2
+	OfferDrawReply_GAME_NOT_FOUND   OfferDrawReply_ResponseStatus = 3
+	OfferDrawReply_ALREADY_OFFERED  OfferDrawReply_ResponseStatus = 4
+	OfferDrawReply_TOO_MANY_PLAYERS OfferDrawReply_ResponseStatus = 5
+)
+
+var OfferDrawReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+	4: "ALREADY_OFFERED",
+	5: "TOO_MANY_PLAYERS",
+}
+var OfferDrawReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":          0,
+	"NOT_PLAYER":       1,
+	"FINISHED":         2,
+	"GAME_NOT_FOUND":   3,
+	"ALREADY_OFFERED":  4,
+	"TOO_MANY_PLAYERS": 5,
+}
+
+func (x OfferDrawReply_ResponseStatus) String() string {
+	return proto.EnumName(OfferDrawReply_ResponseStatus_name, int32(x))
+}
+
+type RespondToDrawReply_ResponseStatus int32
+
+const (
+	RespondToDrawReply_SUCCESS        RespondToDrawReply_ResponseStatus = 0
+	RespondToDrawReply_NOT_PLAYER     RespondToDrawReply_ResponseStatus = 1
+	RespondToDrawReply_FINISHED       RespondToDrawReply_ResponseStatus = 2
+	RespondToDrawReply_GAME_NOT_FOUND RespondToDrawReply_ResponseStatus = 3
+	RespondToDrawReply_NO_OFFER       RespondToDrawReply_ResponseStatus = 4
+)
+
+var RespondToDrawReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+	4: "NO_OFFER",
+}
+var RespondToDrawReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":        0,
+	"NOT_PLAYER":     1,
+	"FINISHED":       2,
+	"GAME_NOT_FOUND": 3,
+	"NO_OFFER":       4,
+}
+
+func (x RespondToDrawReply_ResponseStatus) String() string {
+	return proto.EnumName(RespondToDrawReply_ResponseStatus_name, int32(x))
+}
+
+type TakebackReply_ResponseStatus int32
+
+const (
+	TakebackReply_SUCCESS           TakebackReply_ResponseStatus = 0
+	TakebackReply_NOT_PLAYER        TakebackReply_ResponseStatus = 1
+	TakebackReply_FINISHED          TakebackReply_ResponseStatus = 2
+	TakebackReply_GAME_NOT_FOUND    TakebackReply_ResponseStatus = 3
+	TakebackReply_ALREADY_REQUESTED TakebackReply_ResponseStatus = 4
+	TakebackReply_INVALID_PLIES     TakebackReply_ResponseStatus = 5
+	TakebackReply_TOO_MANY_PLAYERS  TakebackReply_ResponseStatus = 6
+)
+
+var TakebackReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+	4: "ALREADY_REQUESTED",
+	5: "INVALID_PLIES",
+	6: "TOO_MANY_PLAYERS",
+}
+var TakebackReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":           0,
+	"NOT_PLAYER":        1,
+	"FINISHED":          2,
+	"GAME_NOT_FOUND":    3,
+	"ALREADY_REQUESTED": 4,
+	"INVALID_PLIES":     5,
+	"TOO_MANY_PLAYERS":  6,
+}
+
+func (x TakebackReply_ResponseStatus) String() string {
+	return proto.EnumName(TakebackReply_ResponseStatus_name, int32(x))
+}
+
+type RespondToTakebackReply_ResponseStatus int32
+
+const (
+	RespondToTakebackReply_SUCCESS        RespondToTakebackReply_ResponseStatus = 0
+	RespondToTakebackReply_NOT_PLAYER     RespondToTakebackReply_ResponseStatus = 1
+	RespondToTakebackReply_FINISHED       RespondToTakebackReply_ResponseStatus = 2
+	RespondToTakebackReply_GAME_NOT_FOUND RespondToTakebackReply_ResponseStatus = 3
+	RespondToTakebackReply_NO_REQUEST     RespondToTakebackReply_ResponseStatus = 4
+)
+
+var RespondToTakebackReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "FINISHED",
+	3: "GAME_NOT_FOUND",
+	4: "NO_REQUEST",
+}
+var RespondToTakebackReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":        0,
+	"NOT_PLAYER":     1,
+	"FINISHED":       2,
+	"GAME_NOT_FOUND": 3,
+	"NO_REQUEST":     4,
+}
+
+func (x RespondToTakebackReply_ResponseStatus) String() string {
+	return proto.EnumName(RespondToTakebackReply_ResponseStatus_name, int32(x))
+}
+
+type RematchReply_ResponseStatus int32
+
+const (
+	RematchReply_SUCCESS           RematchReply_ResponseStatus = 0
+	RematchReply_NOT_PLAYER        RematchReply_ResponseStatus = 1
+	RematchReply_NOT_FINISHED      RematchReply_ResponseStatus = 2
+	RematchReply_GAME_NOT_FOUND    RematchReply_ResponseStatus = 3
+	RematchReply_ALREADY_REMATCHED RematchReply_ResponseStatus = 4
+)
+
+var RematchReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "NOT_PLAYER",
+	2: "NOT_FINISHED",
+	3: "GAME_NOT_FOUND",
+	4: "ALREADY_REMATCHED",
+}
+var RematchReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":           0,
+	"NOT_PLAYER":        1,
+	"NOT_FINISHED":      2,
+	"GAME_NOT_FOUND":    3,
+	"ALREADY_REMATCHED": 4,
+}
+
+func (x RematchReply_ResponseStatus) String() string {
+	return proto.EnumName(RematchReply_ResponseStatus_name, int32(x))
+}
+
+type MoveAnalysis_Result int32
+
+const (
+	MoveAnalysis_UNKNOWN MoveAnalysis_Result = 0
+	MoveAnalysis_WIN     MoveAnalysis_Result = 1
+	MoveAnalysis_DRAW    MoveAnalysis_Result = 2
+	MoveAnalysis_LOSS    MoveAnalysis_Result = 3
+)
+
+var MoveAnalysis_Result_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "WIN",
+	2: "DRAW",
+	3: "LOSS",
+}
+var MoveAnalysis_Result_value = map[string]int32{
+	"UNKNOWN": 0,
+	"WIN":     1,
+	"DRAW":    2,
+	"LOSS":    3,
+}
+
+func (x MoveAnalysis_Result) String() string {
+	return proto.EnumName(MoveAnalysis_Result_name, int32(x))
+}
+
+type AnalyzeReply_ResponseStatus int32
+
+const (
+	AnalyzeReply_SUCCESS        AnalyzeReply_ResponseStatus = 0
+	AnalyzeReply_GAME_NOT_FOUND AnalyzeReply_ResponseStatus = 1
+	AnalyzeReply_FINISHED       AnalyzeReply_ResponseStatus = 2
+)
+
+var AnalyzeReply_ResponseStatus_name = map[int32]string{
+	0: "SUCCESS",
+	1: "GAME_NOT_FOUND",
+	2: "FINISHED",
+}
+var AnalyzeReply_ResponseStatus_value = map[string]int32{
+	"SUCCESS":        0,
+	"GAME_NOT_FOUND": 1,
+	"FINISHED":       2,
+}
+
+func (x AnalyzeReply_ResponseStatus) String() string {
+	return proto.EnumName(AnalyzeReply_ResponseStatus_name, int32(x))
+}
+
 type Event_Type int32
 
 const (
-	Event_GAME_CREATED Event_Type = 0
-	Event_TURN_PLAYED  Event_Type = 1
+	Event_GAME_CREATED       Event_Type = 0
+	Event_TURN_PLAYED        Event_Type = 1
+	Event_GAME_EXPIRED       Event_Type = 2
+	Event_GAME_TIMED_OUT     Event_Type = 3
+	Event_RESIGNED           Event_Type = 4
+	Event_ABORTED            Event_Type = 5
+	Event_DRAW_OFFERED       Event_Type = 6
+	Event_DRAW_ACCEPTED      Event_Type = 7
+	Event_DRAW_DECLINED      Event_Type = 8
+	Event_TAKEBACK_REQUESTED Event_Type = 9
+	Event_TAKEBACK_DECLINED  Event_Type = 10
+	Event_MOVES_TAKEN_BACK   Event_Type = 11
+	Event_REMATCH_CREATED    Event_Type = 12
 )
 
 var Event_Type_name = map[int32]string{
-	0: "GAME_CREATED",
-	1: "TURN_PLAYED",
+	0:  "GAME_CREATED",
+	1:  "TURN_PLAYED",
+	2:  "GAME_EXPIRED",
+	3:  "GAME_TIMED_OUT",
+	4:  "RESIGNED",
+	5:  "ABORTED",
+	6:  "DRAW_OFFERED",
+	7:  "DRAW_ACCEPTED",
+	8:  "DRAW_DECLINED",
+	9:  "TAKEBACK_REQUESTED",
+	10: "TAKEBACK_DECLINED",
+	11: "MOVES_TAKEN_BACK",
+	12: "REMATCH_CREATED",
 }
 var Event_Type_value = map[string]int32{
-	"GAME_CREATED": 0,
-	"TURN_PLAYED":  1,
+	"GAME_CREATED":       0,
+	"TURN_PLAYED":        1,
+	"GAME_EXPIRED":       2,
+	"GAME_TIMED_OUT":     3,
+	"RESIGNED":           4,
+	"ABORTED":            5,
+	"DRAW_OFFERED":       6,
+	"DRAW_ACCEPTED":      7,
+	"DRAW_DECLINED":      8,
+	"TAKEBACK_REQUESTED": 9,
+	"TAKEBACK_DECLINED":  10,
+	"MOVES_TAKEN_BACK":   11,
+	"REMATCH_CREATED":    12,
 }
 
 func (x Event_Type) String() string {
 	return proto.EnumName(Event_Type_name, int32(x))
 }
 
+type TimeControl struct {
+	// Time a player has for a single move, 0 if moves are not limited.
+	MoveLimitMs int64 `protobuf:"varint,1,opt,name=move_limit_ms" json:"move_limit_ms,omitempty"`
+	// Time on the clock of every player at the start, 0 for no clock.
+	InitialMs int64 `protobuf:"varint,2,opt,name=initial_ms" json:"initial_ms,omitempty"`
+	// Time added to the clock of a player after each of their moves. An
+	// increment without a move limit or a clock is invalid.
+	IncrementMs int64 `protobuf:"varint,3,opt,name=increment_ms" json:"increment_ms,omitempty"`
+}
+
+func (m *TimeControl) Reset()         { *m = TimeControl{} }
+func (m *TimeControl) String() string { return proto.CompactTextString(m) }
+func (*TimeControl) ProtoMessage()    {}
+
+type Clock struct {
+	UserId      string `protobuf:"bytes,1,opt,name=user_id" json:"user_id,omitempty"`
+	RemainingMs int64  `protobuf:"varint,2,opt,name=remaining_ms" json:"remaining_ms,omitempty"`
+}
+
+func (m *Clock) Reset()         { *m = Clock{} }
+func (m *Clock) String() string { return proto.CompactTextString(m) }
+func (*Clock) ProtoMessage()    {}
+
+// Settings of a bot seated in a game.
+type BotSeat struct {
+	UserId string `protobuf:"bytes,1,opt,name=user_id" json:"user_id,omitempty"`
+	// One of random, greedy, minimax and mcts.
+	Strategy string `protobuf:"bytes,2,opt,name=strategy" json:"strategy,omitempty"`
+	// Search depth of minimax, at most 12. 0 searches small boards to the
+	// end.
+	Depth int32 `protobuf:"varint,3,opt,name=depth" json:"depth,omitempty"`
+	// Chance that minimax plays a random move instead of the best one.
+	ErrorRate float64 `protobuf:"fixed64,4,opt,name=error_rate" json:"error_rate,omitempty"`
+	// Number of playouts of mcts, 0 for the default. At most 100000.
+	Iterations int32 `protobuf:"varint,5,opt,name=iterations" json:"iterations,omitempty"`
+}
+
+func (m *BotSeat) Reset()         { *m = BotSeat{} }
+func (m *BotSeat) String() string { return proto.CompactTextString(m) }
+func (*BotSeat) ProtoMessage()    {}
+
 type CreateRequest struct {
-	UserIds []string `protobuf:"bytes,1,rep,name=user_ids" json:"user_ids,omitempty"`
+	// Two to four players in turn order. The players get the marks X, Y, Z
+	// and W in the order they move. A user id of the form "bot:<name>" seats a bot
+	// that moves automatically. The bots random, greedy, easy, medium,
+	// perfect and mcts can be seated without settings.
+	UserIds     []string                `protobuf:"bytes,1,rep,name=user_ids" json:"user_ids,omitempty"`
+	Width       int32                   `protobuf:"varint,2,opt,name=width" json:"width,omitempty"`
+	Height      int32                   `protobuf:"varint,3,opt,name=height" json:"height,omitempty"`
+	WinLength   int32                   `protobuf:"varint,4,opt,name=win_length" json:"win_length,omitempty"`
+	Variant     string                  `protobuf:"bytes,5,opt,name=variant" json:"variant,omitempty"`
+	TimeControl *TimeControl            `protobuf:"bytes,6,opt,name=time_control" json:"time_control,omitempty"`
+	Bots        []*BotSeat              `protobuf:"bytes,7,rep,name=bots" json:"bots,omitempty"`
+	TurnOrder   CreateRequest_TurnOrder `protobuf:"varint,8,opt,name=turn_order,enum=tictactoe.CreateRequest_TurnOrder" json:"turn_order,omitempty"`
 }
 
 func (m *CreateRequest) Reset()         { *m = CreateRequest{} }
 func (m *CreateRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateRequest) ProtoMessage()    {}
 
+func (m *CreateRequest) GetTimeControl() *TimeControl {
+	if m != nil {
+		return m.TimeControl
+	}
+	return nil
+}
+
+func (m *CreateRequest) GetBots() []*BotSeat {
+	if m != nil {
+		return m.Bots
+	}
+	return nil
+}
+
 type CreateReply struct {
-	Status CreateReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.CreateReply_ResponseStatus" json:"status,omitempty"`
-	GameId string                     `protobuf:"bytes,2,opt,name=game_id" json:"game_id,omitempty"`
+	Status   CreateReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.CreateReply_ResponseStatus" json:"status,omitempty"`
+	GameId   string                     `protobuf:"bytes,2,opt,name=game_id" json:"game_id,omitempty"`
+	SeriesId string                     `protobuf:"bytes,3,opt,name=series_id" json:"series_id,omitempty"`
 }
 
 func (m *CreateReply) Reset()         { *m = CreateReply{} }
 func (m *CreateReply) String() string { return proto.CompactTextString(m) }
 func (*CreateReply) ProtoMessage()    {}
 
+// Position of a sub-board on the board of an ultimate game.
+type SubBoard struct {
+	X int32 `protobuf:"varint,1,opt,name=x" json:"x,omitempty"`
+	Y int32 `protobuf:"varint,2,opt,name=y" json:"y,omitempty"`
+}
+
+func (m *SubBoard) Reset()         { *m = SubBoard{} }
+func (m *SubBoard) String() string { return proto.CompactTextString(m) }
+func (*SubBoard) ProtoMessage()    {}
+
 type TurnRequest struct {
 	GameId string              `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
 	UserId string              `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
@@ -183,19 +641,37 @@ func (m *TurnRequest) GetMove() *TurnRequest_Square {
 	return nil
 }
 
+// In ultimate games a square with a board has x and y relative to the
+// sub-board. Squares reported for ultimate games always have a board.
 type TurnRequest_Square struct {
-	X int32 `protobuf:"varint,1,opt,name=x" json:"x,omitempty"`
-	Y int32 `protobuf:"varint,2,opt,name=y" json:"y,omitempty"`
+	X     int32     `protobuf:"varint,1,opt,name=x" json:"x,omitempty"`
+	Y     int32     `protobuf:"varint,2,opt,name=y" json:"y,omitempty"`
+	Board *SubBoard `protobuf:"bytes,3,opt,name=board" json:"board,omitempty"`
 }
 
 func (m *TurnRequest_Square) Reset()         { *m = TurnRequest_Square{} }
 func (m *TurnRequest_Square) String() string { return proto.CompactTextString(m) }
 func (*TurnRequest_Square) ProtoMessage()    {}
 
+func (m *TurnRequest_Square) GetBoard() *SubBoard {
+	if m != nil {
+		return m.Board
+	}
+	return nil
+}
+
 type Winner struct {
-	Draw      bool               `protobuf:"varint,1,opt,name=draw" json:"draw,omitempty"`
-	UserId    string             `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+	Draw   bool   `protobuf:"varint,1,opt,name=draw" json:"draw,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+	// Every line completed by the winning move, from end to end of the run.
 	Locations []*Winner_Location `protobuf:"bytes,3,rep,name=locations" json:"locations,omitempty"`
+	Reason    Winner_Reason      `protobuf:"varint,4,opt,name=reason,enum=tictactoe.Winner_Reason" json:"reason,omitempty"`
+	// Players that were eliminated before the game ended, in order.
+	Eliminated []string `protobuf:"bytes,5,rep,name=eliminated" json:"eliminated,omitempty"`
+	// Players that share a draw.
+	DrawUserIds []string `protobuf:"bytes,6,rep,name=draw_user_ids" json:"draw_user_ids,omitempty"`
+	// Player that lost the game. Only set when a single player lost it.
+	LoserUserId string `protobuf:"bytes,7,opt,name=loser_user_id" json:"loser_user_id,omitempty"`
 }
 
 func (m *Winner) Reset()         { *m = Winner{} }
@@ -212,12 +688,70 @@ func (m *Winner) GetLocations() []*Winner_Location {
 type Winner_Location struct {
 	Direction Winner_Location_Direction `protobuf:"varint,1,opt,name=direction,enum=tictactoe.Winner_Location_Direction" json:"direction,omitempty"`
 	Position  int32                     `protobuf:"varint,2,opt,name=position" json:"position,omitempty"`
+	Start     *TurnRequest_Square       `protobuf:"bytes,3,opt,name=start" json:"start,omitempty"`
+	End       *TurnRequest_Square       `protobuf:"bytes,4,opt,name=end" json:"end,omitempty"`
+	// A line of sub-boards that wins an ultimate game runs from the start
+	// board to the end board. Start and end are not set.
+	StartBoard *SubBoard `protobuf:"bytes,5,opt,name=start_board" json:"start_board,omitempty"`
+	EndBoard   *SubBoard `protobuf:"bytes,6,opt,name=end_board" json:"end_board,omitempty"`
 }
 
 func (m *Winner_Location) Reset()         { *m = Winner_Location{} }
 func (m *Winner_Location) String() string { return proto.CompactTextString(m) }
 func (*Winner_Location) ProtoMessage()    {}
 
+func (m *Winner_Location) GetStart() *TurnRequest_Square {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *Winner_Location) GetEnd() *TurnRequest_Square {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+func (m *Winner_Location) GetStartBoard() *SubBoard {
+	if m != nil {
+		return m.StartBoard
+	}
+	return nil
+}
+
+func (m *Winner_Location) GetEndBoard() *SubBoard {
+	if m != nil {
+		return m.EndBoard
+	}
+	return nil
+}
+
+// Result of a decided sub-board of an ultimate game.
+type SubBoardResult struct {
+	Board  *SubBoard `protobuf:"bytes,1,opt,name=board" json:"board,omitempty"`
+	Winner *Winner   `protobuf:"bytes,2,opt,name=winner" json:"winner,omitempty"`
+}
+
+func (m *SubBoardResult) Reset()         { *m = SubBoardResult{} }
+func (m *SubBoardResult) String() string { return proto.CompactTextString(m) }
+func (*SubBoardResult) ProtoMessage()    {}
+
+func (m *SubBoardResult) GetBoard() *SubBoard {
+	if m != nil {
+		return m.Board
+	}
+	return nil
+}
+
+func (m *SubBoardResult) GetWinner() *Winner {
+	if m != nil {
+		return m.Winner
+	}
+	return nil
+}
+
 type TurnReply struct {
 	Status TurnReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.TurnReply_ResponseStatus" json:"status,omitempty"`
 	MoveId int64                    `protobuf:"varint,2,opt,name=move_id" json:"move_id,omitempty"`
@@ -227,106 +761,773 @@ func (m *TurnReply) Reset()         { *m = TurnReply{} }
 func (m *TurnReply) String() string { return proto.CompactTextString(m) }
 func (*TurnReply) ProtoMessage()    {}
 
+// In gravity games the ranges cover the playable columns on the top row
+// and moves only choose the column.
 type MoveRange struct {
 	FromX int32 `protobuf:"varint,1,opt,name=from_x" json:"from_x,omitempty"`
 	FromY int32 `protobuf:"varint,2,opt,name=from_y" json:"from_y,omitempty"`
 	ToX   int32 `protobuf:"varint,3,opt,name=to_x" json:"to_x,omitempty"`
 	ToY   int32 `protobuf:"varint,4,opt,name=to_y" json:"to_y,omitempty"`
+	// Ultimate games have a range per sub-board with coordinates relative
+	// to the sub-board.
+	Board *SubBoard `protobuf:"bytes,5,opt,name=board" json:"board,omitempty"`
 }
 
 func (m *MoveRange) Reset()         { *m = MoveRange{} }
 func (m *MoveRange) String() string { return proto.CompactTextString(m) }
 func (*MoveRange) ProtoMessage()    {}
 
-type Event struct {
-	Type       Event_Type               `protobuf:"varint,1,opt,name=type,enum=tictactoe.Event_Type" json:"type,omitempty"`
-	Timestamp  int64                    `protobuf:"varint,2,opt,name=timestamp" json:"timestamp,omitempty"`
-	GameId     string                   `protobuf:"bytes,3,opt,name=game_id" json:"game_id,omitempty"`
-	UserId     string                   `protobuf:"bytes,4,opt,name=user_id" json:"user_id,omitempty"`
-	UserList   []string                 `protobuf:"bytes,5,rep,name=user_list" json:"user_list,omitempty"`
-	Move       *TurnRequest_Square      `protobuf:"bytes,6,opt,name=move" json:"move,omitempty"`
-	TurnStatus TurnReply_ResponseStatus `protobuf:"varint,7,opt,name=turn_status,enum=tictactoe.TurnReply_ResponseStatus" json:"turn_status,omitempty"`
-	Winner     *Winner                  `protobuf:"bytes,8,opt,name=winner" json:"winner,omitempty"`
-	MoveId     int64                    `protobuf:"varint,9,opt,name=move_id" json:"move_id,omitempty"`
-	NextPlayer string                   `protobuf:"bytes,10,opt,name=next_player" json:"next_player,omitempty"`
-	ValidMoves []*MoveRange             `protobuf:"bytes,11,rep,name=valid_moves" json:"valid_moves,omitempty"`
+func (m *MoveRange) GetBoard() *SubBoard {
+	if m != nil {
+		return m.Board
+	}
+	return nil
 }
 
-func (m *Event) Reset()         { *m = Event{} }
-func (m *Event) String() string { return proto.CompactTextString(m) }
-func (*Event) ProtoMessage()    {}
+type PlayedMove struct {
+	UserId string              `protobuf:"bytes,1,opt,name=user_id" json:"user_id,omitempty"`
+	Square *TurnRequest_Square `protobuf:"bytes,2,opt,name=square" json:"square,omitempty"`
+}
 
-func (m *Event) GetMove() *TurnRequest_Square {
+func (m *PlayedMove) Reset()         { *m = PlayedMove{} }
+func (m *PlayedMove) String() string { return proto.CompactTextString(m) }
+func (*PlayedMove) ProtoMessage()    {}
+
+func (m *PlayedMove) GetSquare() *TurnRequest_Square {
 	if m != nil {
-		return m.Move
+		return m.Square
 	}
 	return nil
 }
 
-func (m *Event) GetWinner() *Winner {
+type GetGameRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+}
+
+func (m *GetGameRequest) Reset()         { *m = GetGameRequest{} }
+func (m *GetGameRequest) String() string { return proto.CompactTextString(m) }
+func (*GetGameRequest) ProtoMessage()    {}
+
+type GameState struct {
+	GameId    string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	Variant   string `protobuf:"bytes,2,opt,name=variant" json:"variant,omitempty"`
+	Width     int32  `protobuf:"varint,3,opt,name=width" json:"width,omitempty"`
+	Height    int32  `protobuf:"varint,4,opt,name=height" json:"height,omitempty"`
+	WinLength int32  `protobuf:"varint,5,opt,name=win_length" json:"win_length,omitempty"`
+	// Marks on the board in row-major order.
+	Board []Mark `protobuf:"varint,6,rep,packed,name=board,enum=tictactoe.Mark" json:"board,omitempty"`
+	// Players in turn order.
+	Players     []*GameState_Player `protobuf:"bytes,7,rep,name=players" json:"players,omitempty"`
+	TurnNumber  int32               `protobuf:"varint,8,opt,name=turn_number" json:"turn_number,omitempty"`
+	MoveId      int64               `protobuf:"varint,9,opt,name=move_id" json:"move_id,omitempty"`
+	NextPlayer  string              `protobuf:"bytes,10,opt,name=next_player" json:"next_player,omitempty"`
+	ValidMoves  []*MoveRange        `protobuf:"bytes,11,rep,name=valid_moves" json:"valid_moves,omitempty"`
+	Winner      *Winner             `protobuf:"bytes,12,opt,name=winner" json:"winner,omitempty"`
+	TimeControl *TimeControl        `protobuf:"bytes,13,opt,name=time_control" json:"time_control,omitempty"`
+	// Remaining time of the players at the start of the current turn.
+	Clocks []*Clock `protobuf:"bytes,14,rep,name=clocks" json:"clocks,omitempty"`
+	// Time in nanoseconds since the epoch at which the next player runs out
+	// of time, 0 if the turn is not limited.
+	Deadline int64 `protobuf:"varint,15,opt,name=deadline" json:"deadline,omitempty"`
+	// Player with a pending draw offer, empty if there is none.
+	DrawOffer string `protobuf:"bytes,16,opt,name=draw_offer" json:"draw_offer,omitempty"`
+	// Moves in the order they were played.
+	Moves []*PlayedMove `protobuf:"bytes,17,rep,name=moves" json:"moves,omitempty"`
+	// Player with a pending takeback request and the number of plies they
+	// want to take back.
+	TakebackRequestedBy string `protobuf:"bytes,18,opt,name=takeback_requested_by" json:"takeback_requested_by,omitempty"`
+	TakebackPlies       int32  `protobuf:"varint,19,opt,name=takeback_plies" json:"takeback_plies,omitempty"`
+	SeriesId            string `protobuf:"bytes,20,opt,name=series_id" json:"series_id,omitempty"`
+	GameNumber          int32  `protobuf:"varint,21,opt,name=game_number" json:"game_number,omitempty"`
+	// Game that was started as a rematch of this game.
+	RematchGameId string     `protobuf:"bytes,22,opt,name=rematch_game_id" json:"rematch_game_id,omitempty"`
+	Bots          []*BotSeat `protobuf:"bytes,23,rep,name=bots" json:"bots,omitempty"`
+	// Players that were eliminated, in order. They no longer move.
+	Eliminated []string `protobuf:"bytes,24,rep,name=eliminated" json:"eliminated,omitempty"`
+	// Results of the decided sub-boards of an ultimate game.
+	SubBoards []*SubBoardResult `protobuf:"bytes,25,rep,name=sub_boards" json:"sub_boards,omitempty"`
+}
+
+func (m *GameState) Reset()         { *m = GameState{} }
+func (m *GameState) String() string { return proto.CompactTextString(m) }
+func (*GameState) ProtoMessage()    {}
+
+func (m *GameState) GetPlayers() []*GameState_Player {
 	if m != nil {
-		return m.Winner
+		return m.Players
 	}
 	return nil
 }
 
-func (m *Event) GetValidMoves() []*MoveRange {
+func (m *GameState) GetValidMoves() []*MoveRange {
 	if m != nil {
 		return m.ValidMoves
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("tictactoe.Mark", Mark_name, Mark_value)
-	proto.RegisterEnum("tictactoe.CreateReply_ResponseStatus", CreateReply_ResponseStatus_name, CreateReply_ResponseStatus_value)
-	proto.RegisterEnum("tictactoe.Winner_Location_Direction", Winner_Location_Direction_name, Winner_Location_Direction_value)
-	proto.RegisterEnum("tictactoe.TurnReply_ResponseStatus", TurnReply_ResponseStatus_name, TurnReply_ResponseStatus_value)
-	proto.RegisterEnum("tictactoe.Event_Type", Event_Type_name, Event_Type_value)
+func (m *GameState) GetWinner() *Winner {
+	if m != nil {
+		return m.Winner
+	}
+	return nil
 }
 
-// Client API for GameManager service
-
-type GameManagerClient interface {
-	CreateGame(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateReply, error)
-	PlayTurn(ctx context.Context, in *TurnRequest, opts ...grpc.CallOption) (*TurnReply, error)
+func (m *GameState) GetTimeControl() *TimeControl {
+	if m != nil {
+		return m.TimeControl
+	}
+	return nil
 }
 
-type gameManagerClient struct {
-	cc *grpc.ClientConn
+func (m *GameState) GetClocks() []*Clock {
+	if m != nil {
+		return m.Clocks
+	}
+	return nil
 }
 
-func NewGameManagerClient(cc *grpc.ClientConn) GameManagerClient {
-	return &gameManagerClient{cc}
+func (m *GameState) GetMoves() []*PlayedMove {
+	if m != nil {
+		return m.Moves
+	}
+	return nil
 }
 
-func (c *gameManagerClient) CreateGame(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateReply, error) {
-	out := new(CreateReply)
-	err := grpc.Invoke(ctx, "/tictactoe.GameManager/CreateGame", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *GameState) GetBots() []*BotSeat {
+	if m != nil {
+		return m.Bots
 	}
-	return out, nil
+	return nil
 }
 
-func (c *gameManagerClient) PlayTurn(ctx context.Context, in *TurnRequest, opts ...grpc.CallOption) (*TurnReply, error) {
-	out := new(TurnReply)
-	err := grpc.Invoke(ctx, "/tictactoe.GameManager/PlayTurn", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *GameState) GetSubBoards() []*SubBoardResult {
+	if m != nil {
+		return m.SubBoards
 	}
-	return out, nil
+	return nil
 }
 
-// Server API for GameManager service
+type GameState_Player struct {
+	UserId string `protobuf:"bytes,1,opt,name=user_id" json:"user_id,omitempty"`
+	Mark   Mark   `protobuf:"varint,2,opt,name=mark,enum=tictactoe.Mark" json:"mark,omitempty"`
+}
 
-type GameManagerServer interface {
-	CreateGame(context.Context, *CreateRequest) (*CreateReply, error)
-	PlayTurn(context.Context, *TurnRequest) (*TurnReply, error)
+func (m *GameState_Player) Reset()         { *m = GameState_Player{} }
+func (m *GameState_Player) String() string { return proto.CompactTextString(m) }
+func (*GameState_Player) ProtoMessage()    {}
+
+type GetGameReply struct {
+	Game *GameState `protobuf:"bytes,1,opt,name=game" json:"game,omitempty"`
 }
 
-func RegisterGameManagerServer(s *grpc.Server, srv GameManagerServer) {
-	s.RegisterService(&_GameManager_serviceDesc, srv)
+func (m *GetGameReply) Reset()         { *m = GetGameReply{} }
+func (m *GetGameReply) String() string { return proto.CompactTextString(m) }
+func (*GetGameReply) ProtoMessage()    {}
+
+func (m *GetGameReply) GetGame() *GameState {
+	if m != nil {
+		return m.Game
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+
+// The first reply of a watch contains a snapshot of the game, every
+// following reply an event of the game.
+type WatchReply struct {
+	Snapshot *GameState `protobuf:"bytes,1,opt,name=snapshot" json:"snapshot,omitempty"`
+	Event    *Event     `protobuf:"bytes,2,opt,name=event" json:"event,omitempty"`
+}
+
+func (m *WatchReply) Reset()         { *m = WatchReply{} }
+func (m *WatchReply) String() string { return proto.CompactTextString(m) }
+func (*WatchReply) ProtoMessage()    {}
+
+func (m *WatchReply) GetSnapshot() *GameState {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+func (m *WatchReply) GetEvent() *Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+type ResignRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+}
+
+func (m *ResignRequest) Reset()         { *m = ResignRequest{} }
+func (m *ResignRequest) String() string { return proto.CompactTextString(m) }
+func (*ResignRequest) ProtoMessage()    {}
+
+type ResignReply struct {
+	Status ResignReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.ResignReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *ResignReply) Reset()         { *m = ResignReply{} }
+func (m *ResignReply) String() string { return proto.CompactTextString(m) }
+func (*ResignReply) ProtoMessage()    {}
+
+// Aborting a game before the first move ends it without a result, aborting
+// it later counts as a loss.
+type AbortRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+}
+
+func (m *AbortRequest) Reset()         { *m = AbortRequest{} }
+func (m *AbortRequest) String() string { return proto.CompactTextString(m) }
+func (*AbortRequest) ProtoMessage()    {}
+
+type AbortReply struct {
+	Status AbortReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.AbortReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *AbortReply) Reset()         { *m = AbortReply{} }
+func (m *AbortReply) String() string { return proto.CompactTextString(m) }
+func (*AbortReply) ProtoMessage()    {}
+
+// A draw offer stays open until the opponent responds or the offering
+// player moves.
+type OfferDrawRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+}
+
+func (m *OfferDrawRequest) Reset()         { *m = OfferDrawRequest{} }
+func (m *OfferDrawRequest) String() string { return proto.CompactTextString(m) }
+func (*OfferDrawRequest) ProtoMessage()    {}
+
+type OfferDrawReply struct {
+	Status OfferDrawReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.OfferDrawReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *OfferDrawReply) Reset()         { *m = OfferDrawReply{} }
+func (m *OfferDrawReply) String() string { return proto.CompactTextString(m) }
+func (*OfferDrawReply) ProtoMessage()    {}
+
+type RespondToDrawRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+	Accept bool   `protobuf:"varint,3,opt,name=accept" json:"accept,omitempty"`
+}
+
+func (m *RespondToDrawRequest) Reset()         { *m = RespondToDrawRequest{} }
+func (m *RespondToDrawRequest) String() string { return proto.CompactTextString(m) }
+func (*RespondToDrawRequest) ProtoMessage()    {}
+
+type RespondToDrawReply struct {
+	Status RespondToDrawReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.RespondToDrawReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *RespondToDrawReply) Reset()         { *m = RespondToDrawReply{} }
+func (m *RespondToDrawReply) String() string { return proto.CompactTextString(m) }
+func (*RespondToDrawReply) ProtoMessage()    {}
+
+// A takeback removes the last plies of the game when the opponent agrees.
+// Pending requests are dropped when a move is played.
+type TakebackRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+	// Number of moves to take back, 1 if not set.
+	Plies int32 `protobuf:"varint,3,opt,name=plies" json:"plies,omitempty"`
+}
+
+func (m *TakebackRequest) Reset()         { *m = TakebackRequest{} }
+func (m *TakebackRequest) String() string { return proto.CompactTextString(m) }
+func (*TakebackRequest) ProtoMessage()    {}
+
+type TakebackReply struct {
+	Status TakebackReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.TakebackReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *TakebackReply) Reset()         { *m = TakebackReply{} }
+func (m *TakebackReply) String() string { return proto.CompactTextString(m) }
+func (*TakebackReply) ProtoMessage()    {}
+
+type RespondToTakebackRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+	Accept bool   `protobuf:"varint,3,opt,name=accept" json:"accept,omitempty"`
+}
+
+func (m *RespondToTakebackRequest) Reset()         { *m = RespondToTakebackRequest{} }
+func (m *RespondToTakebackRequest) String() string { return proto.CompactTextString(m) }
+func (*RespondToTakebackRequest) ProtoMessage()    {}
+
+type RespondToTakebackReply struct {
+	Status RespondToTakebackReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.RespondToTakebackReply_ResponseStatus" json:"status,omitempty"`
+}
+
+func (m *RespondToTakebackReply) Reset()         { *m = RespondToTakebackReply{} }
+func (m *RespondToTakebackReply) String() string { return proto.CompactTextString(m) }
+func (*RespondToTakebackReply) ProtoMessage()    {}
+
+// Games started with Rematch form a series with the original game.
+type Series struct {
+	SeriesId string `protobuf:"bytes,1,opt,name=series_id" json:"series_id,omitempty"`
+	// Latest game of the series and its number, starting at 1.
+	GameId     string `protobuf:"bytes,2,opt,name=game_id" json:"game_id,omitempty"`
+	GameNumber int32  `protobuf:"varint,3,opt,name=game_number" json:"game_number,omitempty"`
+	// Results of the finished games of the series.
+	Scores []*Series_Score `protobuf:"bytes,4,rep,name=scores" json:"scores,omitempty"`
+	Draws  int32           `protobuf:"varint,5,opt,name=draws" json:"draws,omitempty"`
+}
+
+func (m *Series) Reset()         { *m = Series{} }
+func (m *Series) String() string { return proto.CompactTextString(m) }
+func (*Series) ProtoMessage()    {}
+
+func (m *Series) GetScores() []*Series_Score {
+	if m != nil {
+		return m.Scores
+	}
+	return nil
+}
+
+type Series_Score struct {
+	UserId string `protobuf:"bytes,1,opt,name=user_id" json:"user_id,omitempty"`
+	Wins   int32  `protobuf:"varint,2,opt,name=wins" json:"wins,omitempty"`
+}
+
+func (m *Series_Score) Reset()         { *m = Series_Score{} }
+func (m *Series_Score) String() string { return proto.CompactTextString(m) }
+func (*Series_Score) ProtoMessage()    {}
+
+// A rematch starts a new game in the series of a finished game. The turn
+// order is rotated, so the player that moved second starts and the marks
+// move along with it.
+type RematchRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id" json:"user_id,omitempty"`
+}
+
+func (m *RematchRequest) Reset()         { *m = RematchRequest{} }
+func (m *RematchRequest) String() string { return proto.CompactTextString(m) }
+func (*RematchRequest) ProtoMessage()    {}
+
+type RematchReply struct {
+	Status RematchReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.RematchReply_ResponseStatus" json:"status,omitempty"`
+	// The new game, or the existing rematch if there already is one.
+	GameId string `protobuf:"bytes,2,opt,name=game_id" json:"game_id,omitempty"`
+}
+
+func (m *RematchReply) Reset()         { *m = RematchReply{} }
+func (m *RematchReply) String() string { return proto.CompactTextString(m) }
+func (*RematchReply) ProtoMessage()    {}
+
+type GetSeriesRequest struct {
+	SeriesId string `protobuf:"bytes,1,opt,name=series_id" json:"series_id,omitempty"`
+}
+
+func (m *GetSeriesRequest) Reset()         { *m = GetSeriesRequest{} }
+func (m *GetSeriesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSeriesRequest) ProtoMessage()    {}
+
+type GetSeriesReply struct {
+	Series *Series `protobuf:"bytes,1,opt,name=series" json:"series,omitempty"`
+}
+
+func (m *GetSeriesReply) Reset()         { *m = GetSeriesReply{} }
+func (m *GetSeriesReply) String() string { return proto.CompactTextString(m) }
+func (*GetSeriesReply) ProtoMessage()    {}
+
+func (m *GetSeriesReply) GetSeries() *Series {
+	if m != nil {
+		return m.Series
+	}
+	return nil
+}
+
+// Analyzes the position of a game or, if no game id is given, a supplied
+// board.
+// Boards larger than 15 squares on a side are not analyzed. Moves that
+// could not be searched within the time limit of the analysis are
+// UNKNOWN.
+type AnalyzeRequest struct {
+	GameId string `protobuf:"bytes,1,opt,name=game_id" json:"game_id,omitempty"`
+	// Analyze the position this many moves before the current one.
+	MovesBack int32  `protobuf:"varint,2,opt,name=moves_back" json:"moves_back,omitempty"`
+	Variant   string `protobuf:"bytes,3,opt,name=variant" json:"variant,omitempty"`
+	Width     int32  `protobuf:"varint,4,opt,name=width" json:"width,omitempty"`
+	Height    int32  `protobuf:"varint,5,opt,name=height" json:"height,omitempty"`
+	WinLength int32  `protobuf:"varint,6,opt,name=win_length" json:"win_length,omitempty"`
+	// Marks on the board in row-major order. In the gravity variant every
+	// mark rests on the bottom row or on another mark.
+	Board []Mark `protobuf:"varint,7,rep,packed,name=board,enum=tictactoe.Mark" json:"board,omitempty"`
+	// Mark of the player to move. It follows from the marks on the board: X
+	// moves unless X has one mark more than Y, and in Notakto, where both
+	// players place X, the second player moves after an odd number of
+	// marks. A board with other counts or a different mark set here is
+	// invalid.
+	ToMove Mark `protobuf:"varint,8,opt,name=to_move,enum=tictactoe.Mark" json:"to_move,omitempty"`
+}
+
+func (m *AnalyzeRequest) Reset()         { *m = AnalyzeRequest{} }
+func (m *AnalyzeRequest) String() string { return proto.CompactTextString(m) }
+func (*AnalyzeRequest) ProtoMessage()    {}
+
+// Result the player to move can force with a move, assuming best play by
+// both players. The distance is the number of moves until the game is won
+// or lost, counting the move itself.
+type MoveAnalysis struct {
+	Square   *TurnRequest_Square `protobuf:"bytes,1,opt,name=square" json:"square,omitempty"`
+	Result   MoveAnalysis_Result `protobuf:"varint,2,opt,name=result,enum=tictactoe.MoveAnalysis_Result" json:"result,omitempty"`
+	Distance int32               `protobuf:"varint,3,opt,name=distance" json:"distance,omitempty"`
+}
+
+func (m *MoveAnalysis) Reset()         { *m = MoveAnalysis{} }
+func (m *MoveAnalysis) String() string { return proto.CompactTextString(m) }
+func (*MoveAnalysis) ProtoMessage()    {}
+
+func (m *MoveAnalysis) GetSquare() *TurnRequest_Square {
+	if m != nil {
+		return m.Square
+	}
+	return nil
+}
+
+type AnalyzeReply struct {
+	Status   AnalyzeReply_ResponseStatus `protobuf:"varint,1,opt,name=status,enum=tictactoe.AnalyzeReply_ResponseStatus" json:"status,omitempty"`
+	Moves    []*MoveAnalysis             `protobuf:"bytes,2,rep,name=moves" json:"moves,omitempty"`
+	BestMove *TurnRequest_Square         `protobuf:"bytes,3,opt,name=best_move" json:"best_move,omitempty"`
+	// Work done by the solver. Zero if the position was not solved.
+	Nodes        uint64  `protobuf:"varint,4,opt,name=nodes" json:"nodes,omitempty"`
+	CacheHitRate float64 `protobuf:"fixed64,5,opt,name=cache_hit_rate" json:"cache_hit_rate,omitempty"`
+}
+
+func (m *AnalyzeReply) Reset()         { *m = AnalyzeReply{} }
+func (m *AnalyzeReply) String() string { return proto.CompactTextString(m) }
+func (*AnalyzeReply) ProtoMessage()    {}
+
+func (m *AnalyzeReply) GetMoves() []*MoveAnalysis {
+	if m != nil {
+		return m.Moves
+	}
+	return nil
+}
+
+func (m *AnalyzeReply) GetBestMove() *TurnRequest_Square {
+	if m != nil {
+		return m.BestMove
+	}
+	return nil
+}
+
+type Event struct {
+	Type      Event_Type `protobuf:"varint,1,opt,name=type,enum=tictactoe.Event_Type" json:"type,omitempty"`
+	Timestamp int64      `protobuf:"varint,2,opt,name=timestamp" json:"timestamp,omitempty"`
+	GameId    string     `protobuf:"bytes,3,opt,name=game_id" json:"game_id,omitempty"`
+	UserId    string     `protobuf:"bytes,4,opt,name=user_id" json:"user_id,omitempty"`
+	UserList  []string   `protobuf:"bytes,5,rep,name=user_list" json:"user_list,omitempty"`
+	// Square that was marked by a successful turn. In gravity games it is the
+	// square the mark landed on.
+	Move       *TurnRequest_Square      `protobuf:"bytes,6,opt,name=move" json:"move,omitempty"`
+	TurnStatus TurnReply_ResponseStatus `protobuf:"varint,7,opt,name=turn_status,enum=tictactoe.TurnReply_ResponseStatus" json:"turn_status,omitempty"`
+	Winner     *Winner                  `protobuf:"bytes,8,opt,name=winner" json:"winner,omitempty"`
+	MoveId     int64                    `protobuf:"varint,9,opt,name=move_id" json:"move_id,omitempty"`
+	NextPlayer string                   `protobuf:"bytes,10,opt,name=next_player" json:"next_player,omitempty"`
+	ValidMoves []*MoveRange             `protobuf:"bytes,11,rep,name=valid_moves" json:"valid_moves,omitempty"`
+	Width      int32                    `protobuf:"varint,12,opt,name=width" json:"width,omitempty"`
+	Height     int32                    `protobuf:"varint,13,opt,name=height" json:"height,omitempty"`
+	WinLength  int32                    `protobuf:"varint,14,opt,name=win_length" json:"win_length,omitempty"`
+	Variant    string                   `protobuf:"bytes,15,opt,name=variant" json:"variant,omitempty"`
+	// Position of the event in the event sequence of its game, starting at 1.
+	// Events are delivered at least once, consumers should skip events with a
+	// sequence number they have already seen.
+	Sequence    int64        `protobuf:"varint,16,opt,name=sequence" json:"sequence,omitempty"`
+	TimeControl *TimeControl `protobuf:"bytes,17,opt,name=time_control" json:"time_control,omitempty"`
+	Clocks      []*Clock     `protobuf:"bytes,18,rep,name=clocks" json:"clocks,omitempty"`
+	// Number of plies of a takeback.
+	Plies         int32  `protobuf:"varint,19,opt,name=plies" json:"plies,omitempty"`
+	RematchGameId string `protobuf:"bytes,20,opt,name=rematch_game_id" json:"rematch_game_id,omitempty"`
+	// Series of the game. Game creation events carry the score before the
+	// game.
+	Series *Series    `protobuf:"bytes,21,opt,name=series" json:"series,omitempty"`
+	Bots   []*BotSeat `protobuf:"bytes,22,rep,name=bots" json:"bots,omitempty"`
+	// Results of all decided sub-boards of an ultimate game after the move.
+	SubBoards []*SubBoardResult `protobuf:"bytes,23,rep,name=sub_boards" json:"sub_boards,omitempty"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+func (m *Event) GetMove() *TurnRequest_Square {
+	if m != nil {
+		return m.Move
+	}
+	return nil
+}
+
+func (m *Event) GetWinner() *Winner {
+	if m != nil {
+		return m.Winner
+	}
+	return nil
+}
+
+func (m *Event) GetValidMoves() []*MoveRange {
+	if m != nil {
+		return m.ValidMoves
+	}
+	return nil
+}
+
+func (m *Event) GetTimeControl() *TimeControl {
+	if m != nil {
+		return m.TimeControl
+	}
+	return nil
+}
+
+func (m *Event) GetClocks() []*Clock {
+	if m != nil {
+		return m.Clocks
+	}
+	return nil
+}
+
+func (m *Event) GetSeries() *Series {
+	if m != nil {
+		return m.Series
+	}
+	return nil
+}
+
+func (m *Event) GetBots() []*BotSeat {
+	if m != nil {
+		return m.Bots
+	}
+	return nil
+}
+
+func (m *Event) GetSubBoards() []*SubBoardResult {
+	if m != nil {
+		return m.SubBoards
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("tictactoe.Mark", Mark_name, Mark_value)
+	proto.RegisterEnum("tictactoe.CreateRequest_TurnOrder", CreateRequest_TurnOrder_name, CreateRequest_TurnOrder_value)
+	proto.RegisterEnum("tictactoe.CreateReply_ResponseStatus", CreateReply_ResponseStatus_name, CreateReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.Winner_Location_Direction", Winner_Location_Direction_name, Winner_Location_Direction_value)
+	proto.RegisterEnum("tictactoe.Winner_Reason", Winner_Reason_name, Winner_Reason_value)
+	proto.RegisterEnum("tictactoe.TurnReply_ResponseStatus", TurnReply_ResponseStatus_name, TurnReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.ResignReply_ResponseStatus", ResignReply_ResponseStatus_name, ResignReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.AbortReply_ResponseStatus", AbortReply_ResponseStatus_name, AbortReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.OfferDrawReply_ResponseStatus", OfferDrawReply_ResponseStatus_name, OfferDrawReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.RespondToDrawReply_ResponseStatus", RespondToDrawReply_ResponseStatus_name, RespondToDrawReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.TakebackReply_ResponseStatus", TakebackReply_ResponseStatus_name, TakebackReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.RespondToTakebackReply_ResponseStatus", RespondToTakebackReply_ResponseStatus_name, RespondToTakebackReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.RematchReply_ResponseStatus", RematchReply_ResponseStatus_name, RematchReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.MoveAnalysis_Result", MoveAnalysis_Result_name, MoveAnalysis_Result_value)
+	proto.RegisterEnum("tictactoe.AnalyzeReply_ResponseStatus", AnalyzeReply_ResponseStatus_name, AnalyzeReply_ResponseStatus_value)
+	proto.RegisterEnum("tictactoe.Event_Type", Event_Type_name, Event_Type_value)
+}
+
+// Client API for GameManager service
+
+type GameManagerClient interface {
+	CreateGame(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateReply, error)
+	PlayTurn(ctx context.Context, in *TurnRequest, opts ...grpc.CallOption) (*TurnReply, error)
+	GetGame(ctx context.Context, in *GetGameRequest, opts ...grpc.CallOption) (*GetGameReply, error)
+	Resign(ctx context.Context, in *ResignRequest, opts ...grpc.CallOption) (*ResignReply, error)
+	Abort(ctx context.Context, in *AbortRequest, opts ...grpc.CallOption) (*AbortReply, error)
+	OfferDraw(ctx context.Context, in *OfferDrawRequest, opts ...grpc.CallOption) (*OfferDrawReply, error)
+	RespondToDraw(ctx context.Context, in *RespondToDrawRequest, opts ...grpc.CallOption) (*RespondToDrawReply, error)
+	RequestTakeback(ctx context.Context, in *TakebackRequest, opts ...grpc.CallOption) (*TakebackReply, error)
+	RespondToTakeback(ctx context.Context, in *RespondToTakebackRequest, opts ...grpc.CallOption) (*RespondToTakebackReply, error)
+	Rematch(ctx context.Context, in *RematchRequest, opts ...grpc.CallOption) (*RematchReply, error)
+	GetSeries(ctx context.Context, in *GetSeriesRequest, opts ...grpc.CallOption) (*GetSeriesReply, error)
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeReply, error)
+	WatchGame(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (GameManager_WatchGameClient, error)
+}
+
+type gameManagerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGameManagerClient(cc *grpc.ClientConn) GameManagerClient {
+	return &gameManagerClient{cc}
+}
+
+func (c *gameManagerClient) CreateGame(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateReply, error) {
+	out := new(CreateReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/CreateGame", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) PlayTurn(ctx context.Context, in *TurnRequest, opts ...grpc.CallOption) (*TurnReply, error) {
+	out := new(TurnReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/PlayTurn", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) GetGame(ctx context.Context, in *GetGameRequest, opts ...grpc.CallOption) (*GetGameReply, error) {
+	out := new(GetGameReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/GetGame", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) Resign(ctx context.Context, in *ResignRequest, opts ...grpc.CallOption) (*ResignReply, error) {
+	out := new(ResignReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/Resign", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) Abort(ctx context.Context, in *AbortRequest, opts ...grpc.CallOption) (*AbortReply, error) {
+	out := new(AbortReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/Abort", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) OfferDraw(ctx context.Context, in *OfferDrawRequest, opts ...grpc.CallOption) (*OfferDrawReply, error) {
+	out := new(OfferDrawReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/OfferDraw", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) RespondToDraw(ctx context.Context, in *RespondToDrawRequest, opts ...grpc.CallOption) (*RespondToDrawReply, error) {
+	out := new(RespondToDrawReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/RespondToDraw", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) RequestTakeback(ctx context.Context, in *TakebackRequest, opts ...grpc.CallOption) (*TakebackReply, error) {
+	out := new(TakebackReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/RequestTakeback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) RespondToTakeback(ctx context.Context, in *RespondToTakebackRequest, opts ...grpc.CallOption) (*RespondToTakebackReply, error) {
+	out := new(RespondToTakebackReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/RespondToTakeback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) Rematch(ctx context.Context, in *RematchRequest, opts ...grpc.CallOption) (*RematchReply, error) {
+	out := new(RematchReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/Rematch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) GetSeries(ctx context.Context, in *GetSeriesRequest, opts ...grpc.CallOption) (*GetSeriesReply, error) {
+	out := new(GetSeriesReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/GetSeries", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeReply, error) {
+	out := new(AnalyzeReply)
+	err := grpc.Invoke(ctx, "/tictactoe.GameManager/Analyze", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameManagerClient) WatchGame(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (GameManager_WatchGameClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GameManager_serviceDesc.Streams[0], c.cc, "/tictactoe.GameManager/WatchGame", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gameManagerWatchGameClient{stream}
+	if err := x.ClientStream.SendProto(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GameManager_WatchGameClient interface {
+	Recv() (*WatchReply, error)
+	grpc.ClientStream
+}
+
+type gameManagerWatchGameClient struct {
+	grpc.ClientStream
+}
+
+func (x *gameManagerWatchGameClient) Recv() (*WatchReply, error) {
+	m := new(WatchReply)
+	if err := x.ClientStream.RecvProto(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for GameManager service
+
+type GameManagerServer interface {
+	CreateGame(context.Context, *CreateRequest) (*CreateReply, error)
+	PlayTurn(context.Context, *TurnRequest) (*TurnReply, error)
+	GetGame(context.Context, *GetGameRequest) (*GetGameReply, error)
+	Resign(context.Context, *ResignRequest) (*ResignReply, error)
+	Abort(context.Context, *AbortRequest) (*AbortReply, error)
+	OfferDraw(context.Context, *OfferDrawRequest) (*OfferDrawReply, error)
+	RespondToDraw(context.Context, *RespondToDrawRequest) (*RespondToDrawReply, error)
+	RequestTakeback(context.Context, *TakebackRequest) (*TakebackReply, error)
+	RespondToTakeback(context.Context, *RespondToTakebackRequest) (*RespondToTakebackReply, error)
+	Rematch(context.Context, *RematchRequest) (*RematchReply, error)
+	GetSeries(context.Context, *GetSeriesRequest) (*GetSeriesReply, error)
+	Analyze(context.Context, *AnalyzeRequest) (*AnalyzeReply, error)
+	WatchGame(*WatchRequest, GameManager_WatchGameServer) error
+}
+
+func RegisterGameManagerServer(s *grpc.Server, srv GameManagerServer) {
+	s.RegisterService(&_GameManager_serviceDesc, srv)
 }
 
 func _GameManager_CreateGame_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
@@ -353,6 +1554,147 @@ func _GameManager_PlayTurn_Handler(srv interface{}, ctx context.Context, buf []b
 	return out, nil
 }
 
+func _GameManager_GetGame_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(GetGameRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).GetGame(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_Resign_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(ResignRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).Resign(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_Abort_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(AbortRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).Abort(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_OfferDraw_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(OfferDrawRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).OfferDraw(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_RespondToDraw_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(RespondToDrawRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).RespondToDraw(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_RequestTakeback_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(TakebackRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).RequestTakeback(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_RespondToTakeback_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(RespondToTakebackRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).RespondToTakeback(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_Rematch_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(RematchRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).Rematch(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_GetSeries_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(GetSeriesRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).GetSeries(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_Analyze_Handler(srv interface{}, ctx context.Context, buf []byte) (proto.Message, error) {
+	in := new(AnalyzeRequest)
+	if err := proto.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(GameManagerServer).Analyze(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _GameManager_WatchGame_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvProto(m); err != nil {
+		return err
+	}
+	return srv.(GameManagerServer).WatchGame(m, &gameManagerWatchGameServer{stream})
+}
+
+type GameManager_WatchGameServer interface {
+	Send(*WatchReply) error
+	grpc.ServerStream
+}
+
+type gameManagerWatchGameServer struct {
+	grpc.ServerStream
+}
+
+func (x *gameManagerWatchGameServer) Send(m *WatchReply) error {
+	return x.ServerStream.SendProto(m)
+}
+
 var _GameManager_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "tictactoe.GameManager",
 	HandlerType: (*GameManagerServer)(nil),
@@ -365,6 +1707,52 @@ var _GameManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PlayTurn",
 			Handler:    _GameManager_PlayTurn_Handler,
 		},
+		{
+			MethodName: "GetGame",
+			Handler:    _GameManager_GetGame_Handler,
+		},
+		{
+			MethodName: "Resign",
+			Handler:    _GameManager_Resign_Handler,
+		},
+		{
+			MethodName: "Abort",
+			Handler:    _GameManager_Abort_Handler,
+		},
+		{
+			MethodName: "OfferDraw",
+			Handler:    _GameManager_OfferDraw_Handler,
+		},
+		{
+			MethodName: "RespondToDraw",
+			Handler:    _GameManager_RespondToDraw_Handler,
+		},
+		{
+			MethodName: "RequestTakeback",
+			Handler:    _GameManager_RequestTakeback_Handler,
+		},
+		{
+			MethodName: "RespondToTakeback",
+			Handler:    _GameManager_RespondToTakeback_Handler,
+		},
+		{
+			MethodName: "Rematch",
+			Handler:    _GameManager_Rematch_Handler,
+		},
+		{
+			MethodName: "GetSeries",
+			Handler:    _GameManager_GetSeries_Handler,
+		},
+		{
+			MethodName: "Analyze",
+			Handler:    _GameManager_Analyze_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchGame",
+			Handler:       _GameManager_WatchGame_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams: []grpc.StreamDesc{},
 }
@@ -0,0 +1,189 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+const UltimateVariant = "ultimate"
+
+const (
+	// subBoardSize is the number of squares on a side of a sub-board and
+	// the number of sub-boards on a side of the board.
+	subBoardSize = 3
+
+	// anyBoard allows the next player to play in every open sub-board.
+	anyBoard = -1
+)
+
+func init() {
+	RegisterRuleset(UltimateVariant, ultimateRules{})
+}
+
+// ultimateRules are the rules of ultimate tic-tac-toe. The board is made of
+// 3x3 sub-boards of 3x3 squares. The square a player marks sends the next
+// player to the sub-board at the same position, or anywhere if that
+// sub-board is already decided. Three marks in a row win a sub-board and
+// three won sub-boards in a row win the game.
+type ultimateRules struct{}
+
+func (ultimateRules) Setup(g *game, width, height, winLength int) error {
+	size := subBoardSize * subBoardSize
+	if width != 0 && width != size || height != 0 && height != size {
+		return ErrInvalidGridSize
+	}
+	if winLength != 0 && winLength != subBoardSize {
+		return ErrInvalidWinLength
+	}
+	g.Grid = newGameGrid(size, size)
+	g.WinLength = subBoardSize
+	g.SubBoards = make([]*Winner, subBoardSize*subBoardSize)
+	g.NextBoard = anyBoard
+	return nil
+}
+
+func (ultimateRules) ValidateMove(g *game, userID string, x, y int) error {
+	if !g.Grid.coordinatesValid(x, y) || !g.Grid.isEmpty(x, y) || !g.boardOpen(boardIndex(x, y)) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (ultimateRules) ApplyMove(g *game, userID string, x, y int) (int, int) {
+	g.Grid.set(x, y, g.Players[userID])
+
+	b := boardIndex(x, y)
+	sub := g.subGrid(b)
+	if w := lineWinner(sub, subBoardSize, userID, x%subBoardSize, y%subBoardSize); w != nil {
+		for _, loc := range w.Locations {
+			loc.Start.Board = subBoard(b)
+			loc.End.Board = subBoard(b)
+		}
+		g.SubBoards[b] = w
+	} else if sub.isFull() {
+		g.SubBoards[b] = &Winner{Draw: true}
+	}
+
+	g.NextBoard = (y%subBoardSize)*subBoardSize + x%subBoardSize
+	if g.SubBoards[g.NextBoard] != nil {
+		g.NextBoard = anyBoard
+	}
+	return x, y
+}
+
+func (ultimateRules) Outcome(g *game, userID string, x, y int) *Winner {
+	b := boardIndex(x, y)
+	if w := g.SubBoards[b]; w != nil && !w.Draw {
+		if mw := lineWinner(g.metaGrid(), subBoardSize, userID, b%subBoardSize, b/subBoardSize); mw != nil {
+			for _, loc := range mw.Locations {
+				loc.StartBoard = &SubBoard{X: loc.Start.X, Y: loc.Start.Y}
+				loc.EndBoard = &SubBoard{X: loc.End.X, Y: loc.End.Y}
+				loc.Start, loc.End = nil, nil
+			}
+			return mw
+		}
+	}
+	for _, w := range g.SubBoards {
+		if w == nil {
+			return nil
+		}
+	}
+	return &Winner{Draw: true}
+}
+
+func (ultimateRules) LegalMoves(g *game) []*MoveRange {
+	moves := make([]*MoveRange, 0)
+	for b := range g.SubBoards {
+		if !g.boardOpen(b) {
+			continue
+		}
+		for _, r := range emptySquares(g.subGrid(b)) {
+			r.Board = subBoard(b)
+			moves = append(moves, r)
+		}
+	}
+	return moves
+}
+
+func (ultimateRules) NearbyMoves() bool { return true }
+func (ultimateRules) Solvable() bool    { return false }
+
+// boardIndex returns the index of the sub-board that contains (x, y).
+func boardIndex(x, y int) int {
+	return (y/subBoardSize)*subBoardSize + x/subBoardSize
+}
+
+func subBoard(b int) *SubBoard {
+	return &SubBoard{X: int32(b % subBoardSize), Y: int32(b / subBoardSize)}
+}
+
+// boardOpen reports whether the active player may play in sub-board b.
+func (g *game) boardOpen(b int) bool {
+	return g.SubBoards[b] == nil && (g.NextBoard == anyBoard || g.NextBoard == b)
+}
+
+// subGrid returns a copy of sub-board b.
+func (g *game) subGrid(b int) *gameGrid {
+	sub := newGameGrid(subBoardSize, subBoardSize)
+	bx, by := b%subBoardSize*subBoardSize, b/subBoardSize*subBoardSize
+	for y := 0; y < subBoardSize; y++ {
+		for x := 0; x < subBoardSize; x++ {
+			sub.set(x, y, g.Grid.get(bx+x, by+y))
+		}
+	}
+	return sub
+}
+
+// metaGrid returns a grid of the sub-boards with the mark of the player
+// that won each of them.
+func (g *game) metaGrid() *gameGrid {
+	meta := newGameGrid(subBoardSize, subBoardSize)
+	for b, w := range g.SubBoards {
+		if w != nil && !w.Draw {
+			meta.set(b%subBoardSize, b/subBoardSize, g.Players[w.UserId])
+		}
+	}
+	return meta
+}
+
+func (g *game) subBoardResults() []*SubBoardResult {
+	var results []*SubBoardResult
+	for b, w := range g.SubBoards {
+		if w != nil {
+			results = append(results, &SubBoardResult{Board: subBoard(b), Winner: w})
+		}
+	}
+	return results
+}
+
+// squareCoordinates returns the board coordinates of a square. Squares with
+// a sub-board are relative to it.
+func (g *game) squareCoordinates(sq *TurnRequest_Square) (int, int) {
+	if sq.Board == nil {
+		return int(sq.X), int(sq.Y)
+	}
+	if g.SubBoards == nil || !validateIndex(int(sq.X), subBoardSize) || !validateIndex(int(sq.Y), subBoardSize) {
+		return -1, -1
+	}
+	return int(sq.Board.X)*subBoardSize + int(sq.X), int(sq.Board.Y)*subBoardSize + int(sq.Y)
+}
+
+// squareAt returns the square at (x, y) the way it is reported to clients.
+func (g *game) squareAt(x, y int) *TurnRequest_Square {
+	if g.SubBoards == nil {
+		return square(x, y)
+	}
+	sq := square(x%subBoardSize, y%subBoardSize)
+	sq.Board = subBoard(boardIndex(x, y))
+	return sq
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2015 The Protogalaxy Project
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tictactoe
+
+import (
+	"errors"
+	"sync"
+)
+
+// watcherBuffer is the number of events that can be queued for a watcher
+// before it is considered too slow and disconnected.
+const watcherBuffer = 64
+
+var ErrWatcherTooSlow = errors.New("watcher fell too far behind")
+
+type watcher struct {
+	events chan *Event
+	// err is the reason the watcher was disconnected, nil if the game
+	// went away.
+	err error
+}
+
+// watchHub keeps track of the watchers of every game and fans out the
+// events of a game to all of its watchers.
+type watchHub struct {
+	lock     sync.Mutex
+	watchers map[GameID]map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{
+		watchers: make(map[GameID]map[*watcher]struct{}),
+	}
+}
+
+func (h *watchHub) subscribe(id GameID) *watcher {
+	w := &watcher{events: make(chan *Event, watcherBuffer)}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	ws, ok := h.watchers[id]
+	if !ok {
+		ws = make(map[*watcher]struct{})
+		h.watchers[id] = ws
+	}
+	ws[w] = struct{}{}
+	return w
+}
+
+func (h *watchHub) unsubscribe(id GameID, w *watcher) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.remove(id, w)
+}
+
+// broadcast queues the event for every watcher of the game. Watchers that
+// cannot keep up are disconnected instead of blocking the game.
+func (h *watchHub) broadcast(ev *Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	id := GameID(ev.GameId)
+	for w := range h.watchers[id] {
+		select {
+		case w.events <- ev:
+		default:
+			w.err = ErrWatcherTooSlow
+			h.remove(id, w)
+		}
+	}
+}
+
+// closeGame disconnects all watchers of the game.
+func (h *watchHub) closeGame(id GameID) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for w := range h.watchers[id] {
+		h.remove(id, w)
+	}
+}
+
+func (h *watchHub) remove(id GameID, w *watcher) {
+	ws, ok := h.watchers[id]
+	if !ok {
+		return
+	}
+	if _, ok := ws[w]; !ok {
+		return
+	}
+	delete(ws, w)
+	close(w.events)
+	if len(ws) == 0 {
+		delete(h.watchers, id)
+	}
+}